@@ -1,22 +1,80 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"hotel-data-merge/config"
 	"hotel-data-merge/infra"
 	"hotel-data-merge/pkg/cache"
+	"hotel-data-merge/pkg/scheduler"
 	"hotel-data-merge/srv"
 	"hotel-data-merge/usecase"
 	"log"
 	"net/http"
-	"time"
 )
 
 func main() {
-	repo := infra.NewHotelRepo(nil)
-	cache := cache.NewGoCacheWrapper(60*time.Minute, 75*time.Minute)
-	usecase := usecase.NewHotelUsecase(repo, cache)
+	configPath := flag.String("config", "config/config.yaml", "path to the yaml or json config file")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repo, err := infra.NewHotelRepo(nil, cfg.Suppliers)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mergeRules, err := infra.NewMergeRules(cfg.Merge)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cache := cache.NewGoCacheWrapper(cfg.Cache.HardTTL, cfg.Cache.HardTTL)
+	usecase := usecase.NewHotelUsecase(repo, cache, usecase.CacheConfig{
+		SoftTTL: cfg.Cache.SoftTTL,
+		HardTTL: cfg.Cache.HardTTL,
+	}, mergeRules)
+	if cfg.Ingestion.Enabled {
+		startIngestion(context.Background(), cfg, usecase)
+	}
+
 	handler := srv.NewHotelHandler(usecase)
+	adminHandler := srv.NewAdminHandler(usecase)
 
 	// Set up HTTP server
 	http.HandleFunc("/hotels", handler.ListHotelsHandler)
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	http.HandleFunc("/hotels/suggest", handler.SuggestHandler)
+	http.HandleFunc("/hotels/", handler.GetHotelHandler)
+	http.HandleFunc("/admin/health", adminHandler.HealthHandler)
+	http.HandleFunc("/admin/conflicts", adminHandler.ConflictsHandler)
+	log.Fatal(http.ListenAndServe(cfg.Server.Addr, nil))
+}
+
+// startIngestion warms the supplier data before the server starts, then refreshes every supplier
+// in the background on its own schedule, or on the ingestion interval if it has none
+func startIngestion(ctx context.Context, cfg *config.Config, hotelUsecase *usecase.HotelUsecase) {
+	hotelUsecase.Refresh(ctx)
+
+	s := scheduler.New()
+	for _, supplier := range cfg.Suppliers {
+		if !supplier.Enabled {
+			continue
+		}
+
+		var schedule scheduler.Schedule = scheduler.Every(cfg.Ingestion.Interval)
+		if supplier.Schedule != "" {
+			// the schedule is already validated when the config is loaded
+			schedule, _ = scheduler.Parse(supplier.Schedule)
+		}
+
+		name := supplier.Name
+		s.Add(name, schedule, func(ctx context.Context) {
+			hotelUsecase.RefreshSupplier(ctx, name)
+		})
+	}
+
+	s.Start(ctx)
 }
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"hotel-data-merge/pkg/scheduler"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultAddr            = ":8080"
+	defaultSupplierTimeout = 10 * time.Second
+
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 200 * time.Millisecond
+	defaultRetryMaxBackoff     = 2 * time.Second
+	defaultRetryMultiplier     = 2
+	defaultRetryJitter         = 0.2
+
+	defaultCircuitBreakerFailureThreshold = 3
+	defaultCircuitBreakerCoolDown         = 30 * time.Second
+
+	defaultIngestionInterval = 15 * time.Minute
+
+	defaultCacheSoftTTL = 60 * time.Minute
+	defaultCacheHardTTL = 75 * time.Minute
+)
+
+var defaultRetryableStatusCodes = []int{429, 502, 503, 504}
+
+type Config struct {
+	Server    ServerConfig     `yaml:"server"`
+	Cache     CacheConfig      `yaml:"cache"`
+	Ingestion IngestionConfig  `yaml:"ingestion"`
+	Suppliers []SupplierConfig `yaml:"suppliers"`
+	Merge     MergeConfig      `yaml:"merge"`
+}
+
+type ServerConfig struct {
+	Addr string `yaml:"addr"`
+}
+
+// CacheConfig controls how long hotels fetched on a request are cached, when ingestion is disabled
+type CacheConfig struct {
+	SoftTTL time.Duration `yaml:"soft_ttl"` // after this the cached hotels are still served but refreshed in the background
+	HardTTL time.Duration `yaml:"hard_ttl"` // after this the cached hotels are no longer served
+}
+
+// IngestionConfig controls the background refresh of supplier data. When disabled, suppliers are fetched
+// when a request does not find the hotels in the cache
+type IngestionConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"` // refresh interval of suppliers without their own schedule
+}
+
+// MergeConfig selects how the values of the suppliers are merged into a hotel by field, eg. location.city.
+// Fields that are not configured keep their default strategy
+type MergeConfig struct {
+	Fields    map[string]FieldMergeConfig `yaml:"fields"`
+	Conflicts ConflictsConfig             `yaml:"conflicts"`
+}
+
+// ConflictsConfig overrides the default conflict thresholds of the merge rules. Thresholds that are not set keep their default
+type ConflictsConfig struct {
+	MaxDistanceKm       *float64 `yaml:"max_distance_km"`
+	MinNameSimilarity   *float64 `yaml:"min_name_similarity"`
+	IgnoreCoordinates   bool     `yaml:"ignore_coordinates"`
+	IgnoreName          bool     `yaml:"ignore_name"`
+	IgnoreCountry       bool     `yaml:"ignore_country"`
+	IgnoreDestinationID bool     `yaml:"ignore_destination_id"`
+}
+
+// FieldMergeConfig is the merge strategy of a field
+type FieldMergeConfig struct {
+	Strategy  string   `yaml:"strategy"`
+	Suppliers []string `yaml:"suppliers,omitempty"` // priority strategy only, the preferred suppliers in order
+}
+
+// SupplierConfig describes a single supplier endpoint that hotel data is pulled from
+type SupplierConfig struct {
+	Name     string         `yaml:"name"`
+	URL      string         `yaml:"url"`
+	Format   string         `yaml:"format"`
+	Timeout  time.Duration  `yaml:"timeout"`
+	Enabled  bool           `yaml:"enabled"`
+	Priority int            `yaml:"priority"` // lower value means higher priority
+	Mapping  *MappingConfig `yaml:"mapping,omitempty"`
+	Retry    RetryConfig    `yaml:"retry"`
+	// CircuitBreaker stops calling the supplier after consecutive failures
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+	// Schedule is a cron-like refresh schedule of the supplier, eg. "*/10 * * * *" or "@every 5m"
+	Schedule string `yaml:"schedule,omitempty"`
+}
+
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"` // consecutive failed fetches before the circuit opens
+	CoolDown         time.Duration `yaml:"cool_down"`         // how long the circuit stays open before a trial fetch
+}
+
+// RetryConfig is the retry policy of a supplier. All retries are bounded by the supplier timeout
+type RetryConfig struct {
+	MaxAttempts          int           `yaml:"max_attempts"` // 1 disables retries
+	InitialBackoff       time.Duration `yaml:"initial_backoff"`
+	MaxBackoff           time.Duration `yaml:"max_backoff"`
+	Multiplier           float64       `yaml:"multiplier"`
+	Jitter               *float64      `yaml:"jitter"` // fraction of the backoff that is randomized, eg. 0.2 is +-20%, 0 disables it
+	RetryableStatusCodes []int         `yaml:"retryable_status_codes"`
+}
+
+// MappingConfig describes how a supplier response is normalized into hotels, so that
+// a new supplier only needs a config entry instead of its own fetcher
+type MappingConfig struct {
+	Root   string         `yaml:"root,omitempty"` // path to the list of hotels, empty if the response is the list itself
+	Fields []FieldMapping `yaml:"fields"`
+}
+
+// FieldMapping maps one or more dot separated source paths (eg. location.address) to a hotel field
+type FieldMapping struct {
+	Target      string   `yaml:"target"`
+	Source      string   `yaml:"source,omitempty"`
+	Sources     []string `yaml:"sources,omitempty"`
+	Transform   string   `yaml:"transform,omitempty"`
+	Separator   string   `yaml:"separator,omitempty"`
+	Link        string   `yaml:"link,omitempty"`        // image targets only, path of the link in each image
+	Description string   `yaml:"description,omitempty"` // image targets only, path of the description in each image
+}
+
+// Paths returns all source paths of the field
+func (f FieldMapping) Paths() []string {
+	if f.Source == "" {
+		return f.Sources
+	}
+
+	return append([]string{f.Source}, f.Sources...)
+}
+
+// Load reads the config file at path. Both yaml and json files are supported since json is a subset of yaml
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	cfg.setDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) setDefaults() {
+	if c.Server.Addr == "" {
+		c.Server.Addr = defaultAddr
+	}
+
+	if c.Cache.SoftTTL == 0 {
+		c.Cache.SoftTTL = defaultCacheSoftTTL
+	}
+
+	if c.Cache.HardTTL == 0 {
+		c.Cache.HardTTL = defaultCacheHardTTL
+	}
+
+	if c.Ingestion.Interval == 0 {
+		c.Ingestion.Interval = defaultIngestionInterval
+	}
+
+	for i := range c.Suppliers {
+		if c.Suppliers[i].Timeout == 0 {
+			c.Suppliers[i].Timeout = defaultSupplierTimeout
+		}
+		c.Suppliers[i].Retry.setDefaults()
+		c.Suppliers[i].CircuitBreaker.setDefaults()
+	}
+}
+
+func (b *CircuitBreakerConfig) setDefaults() {
+	if b.FailureThreshold == 0 {
+		b.FailureThreshold = defaultCircuitBreakerFailureThreshold
+	}
+
+	if b.CoolDown == 0 {
+		b.CoolDown = defaultCircuitBreakerCoolDown
+	}
+}
+
+func (r *RetryConfig) setDefaults() {
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = defaultRetryMaxAttempts
+	}
+
+	if r.InitialBackoff == 0 {
+		r.InitialBackoff = defaultRetryInitialBackoff
+	}
+
+	if r.MaxBackoff == 0 {
+		r.MaxBackoff = defaultRetryMaxBackoff
+	}
+
+	if r.Multiplier == 0 {
+		r.Multiplier = defaultRetryMultiplier
+	}
+
+	if r.Jitter == nil {
+		jitter := defaultRetryJitter
+		r.Jitter = &jitter
+	}
+
+	if len(r.RetryableStatusCodes) == 0 {
+		r.RetryableStatusCodes = defaultRetryableStatusCodes
+	}
+}
+
+// Validate checks that every supplier has the fields needed to fetch from it.
+// Whether the format is supported is checked by the repository that builds the fetchers,
+// and whether the merge fields and strategies are supported is checked when the merge rules are built
+func (c *Config) Validate() error {
+	var errs []error
+	names := map[string]bool{}
+
+	if c.Cache.SoftTTL < 0 || c.Cache.HardTTL < 0 {
+		errs = append(errs, fmt.Errorf("cache: ttl must not be negative"))
+	} else if c.Cache.SoftTTL > c.Cache.HardTTL {
+		errs = append(errs, fmt.Errorf("cache: soft_ttl must not be longer than hard_ttl"))
+	}
+
+	if c.Ingestion.Interval < 0 {
+		errs = append(errs, fmt.Errorf("ingestion: interval must not be negative"))
+	}
+
+	for i, s := range c.Suppliers {
+		if s.Name == "" {
+			errs = append(errs, fmt.Errorf("suppliers[%d]: name is required", i))
+		} else if names[s.Name] {
+			errs = append(errs, fmt.Errorf("suppliers[%d]: duplicate supplier name %q", i, s.Name))
+		}
+		names[s.Name] = true
+
+		if s.URL == "" {
+			errs = append(errs, fmt.Errorf("suppliers[%d]: url is required", i))
+		}
+
+		if s.Format == "" {
+			errs = append(errs, fmt.Errorf("suppliers[%d]: format is required", i))
+		}
+
+		if s.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("suppliers[%d]: timeout must not be negative", i))
+		}
+
+		if s.Retry.MaxAttempts < 0 || s.Retry.InitialBackoff < 0 || s.Retry.MaxBackoff < 0 || s.Retry.Multiplier < 0 {
+			errs = append(errs, fmt.Errorf("suppliers[%d]: retry values must not be negative", i))
+		}
+
+		if s.Retry.Jitter != nil && (*s.Retry.Jitter < 0 || *s.Retry.Jitter > 1) {
+			errs = append(errs, fmt.Errorf("suppliers[%d]: retry jitter must be between 0 and 1", i))
+		}
+
+		if s.CircuitBreaker.FailureThreshold < 0 || s.CircuitBreaker.CoolDown < 0 {
+			errs = append(errs, fmt.Errorf("suppliers[%d]: circuit breaker values must not be negative", i))
+		}
+
+		if s.Schedule != "" {
+			if _, err := scheduler.Parse(s.Schedule); err != nil {
+				errs = append(errs, fmt.Errorf("suppliers[%d]: %v", i, err))
+			}
+		}
+	}
+
+	if d := c.Merge.Conflicts.MaxDistanceKm; d != nil && *d < 0 {
+		errs = append(errs, fmt.Errorf("merge.conflicts: max_distance_km must not be negative"))
+	}
+
+	if s := c.Merge.Conflicts.MinNameSimilarity; s != nil && (*s < 0 || *s > 1) {
+		errs = append(errs, fmt.Errorf("merge.conflicts: min_name_similarity must be between 0 and 1"))
+	}
+
+	fields := make([]string, 0, len(c.Merge.Fields))
+	for field := range c.Merge.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		f := c.Merge.Fields[field]
+		if f.Strategy == "" {
+			errs = append(errs, fmt.Errorf("merge.fields.%s: strategy is required", field))
+		}
+
+		for _, supplier := range f.Suppliers {
+			if !names[supplier] {
+				errs = append(errs, fmt.Errorf("merge.fields.%s: unknown supplier %q", field, supplier))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("should successfully load yaml config with defaults", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", `
+suppliers:
+  - name: mock-supplier
+    url: http://mock-host/suppliers/mock-supplier
+    format: acme
+    enabled: true
+    priority: 1
+`)
+
+		cfg, err := Load(path)
+		jitter := defaultRetryJitter
+
+		assert.NoError(t, err)
+		assert.Equal(t, defaultAddr, cfg.Server.Addr)
+		assert.Equal(t, CacheConfig{SoftTTL: defaultCacheSoftTTL, HardTTL: defaultCacheHardTTL}, cfg.Cache)
+		assert.Equal(t, IngestionConfig{Interval: defaultIngestionInterval}, cfg.Ingestion)
+		assert.Equal(t, ConflictsConfig{}, cfg.Merge.Conflicts)
+		assert.Equal(t, []SupplierConfig{
+			{
+				Name:     "mock-supplier",
+				URL:      "http://mock-host/suppliers/mock-supplier",
+				Format:   "acme",
+				Timeout:  defaultSupplierTimeout,
+				Enabled:  true,
+				Priority: 1,
+				Retry: RetryConfig{
+					MaxAttempts:          defaultRetryMaxAttempts,
+					InitialBackoff:       defaultRetryInitialBackoff,
+					MaxBackoff:           defaultRetryMaxBackoff,
+					Multiplier:           defaultRetryMultiplier,
+					Jitter:               &jitter,
+					RetryableStatusCodes: defaultRetryableStatusCodes,
+				},
+				CircuitBreaker: CircuitBreakerConfig{
+					FailureThreshold: defaultCircuitBreakerFailureThreshold,
+					CoolDown:         defaultCircuitBreakerCoolDown,
+				},
+			},
+		}, cfg.Suppliers)
+	})
+
+	t.Run("should successfully load json config", func(t *testing.T) {
+		path := writeConfigFile(t, "config.json", `{
+			"server": {"addr": ":9090"},
+			"suppliers": [{"name": "mock-supplier", "url": "http://mock-host", "format": "acme", "timeout": "2s", "enabled": true}]
+		}`)
+
+		cfg, err := Load(path)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ":9090", cfg.Server.Addr)
+		assert.Equal(t, 2*time.Second, cfg.Suppliers[0].Timeout)
+	})
+
+	t.Run("should keep a zero retry jitter", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", `
+suppliers:
+  - name: mock-supplier
+    url: http://mock-host
+    format: acme
+    retry:
+      jitter: 0
+`)
+
+		cfg, err := Load(path)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, *cfg.Suppliers[0].Retry.Jitter)
+	})
+
+	t.Run("should fail on missing and duplicate supplier fields", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", `
+suppliers:
+  - name: mock-supplier
+    url: http://mock-host
+    format: acme
+  - name: mock-supplier
+    schedule: "61 * * * *"
+`)
+
+		cfg, err := Load(path)
+
+		assert.Nil(t, cfg)
+		assert.ErrorContains(t, err, `duplicate supplier name "mock-supplier"`)
+		assert.ErrorContains(t, err, "suppliers[1]: url is required")
+		assert.ErrorContains(t, err, "suppliers[1]: format is required")
+		assert.ErrorContains(t, err, `suppliers[1]: invalid schedule "61 * * * *"`)
+	})
+
+	t.Run("should fail on soft ttl longer than hard ttl", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", `
+cache:
+  soft_ttl: 10m
+  hard_ttl: 5m
+`)
+
+		_, err := Load(path)
+
+		assert.ErrorContains(t, err, "cache: soft_ttl must not be longer than hard_ttl")
+	})
+
+	t.Run("should load merge strategies and fail on unknown suppliers", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", `
+suppliers:
+  - name: acme
+    url: http://mock-host
+    format: acme
+merge:
+  fields:
+    location.city: { strategy: priority, suppliers: [acme] }
+    name: { strategy: majority }
+`)
+
+		cfg, err := Load(path)
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]FieldMergeConfig{
+			"location.city": {Strategy: "priority", Suppliers: []string{"acme"}},
+			"name":          {Strategy: "majority"},
+		}, cfg.Merge.Fields)
+
+		path = writeConfigFile(t, "config.yaml", `
+merge:
+  fields:
+    location.city: { strategy: priority, suppliers: [paperflies] }
+    name: {}
+  conflicts:
+    min_name_similarity: 1.5
+`)
+
+		_, err = Load(path)
+
+		assert.ErrorContains(t, err, `merge.fields.location.city: unknown supplier "paperflies"`)
+		assert.ErrorContains(t, err, "merge.fields.name: strategy is required")
+		assert.ErrorContains(t, err, "merge.conflicts: min_name_similarity must be between 0 and 1")
+	})
+
+	t.Run("should keep zero conflict thresholds", func(t *testing.T) {
+		path := writeConfigFile(t, "config.yaml", `
+merge:
+  conflicts:
+    max_distance_km: 0
+    min_name_similarity: 0
+    ignore_coordinates: true
+    ignore_name: true
+`)
+
+		cfg, err := Load(path)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, *cfg.Merge.Conflicts.MaxDistanceKm)
+		assert.Equal(t, 0.0, *cfg.Merge.Conflicts.MinNameSimilarity)
+		assert.True(t, cfg.Merge.Conflicts.IgnoreCoordinates)
+		assert.True(t, cfg.Merge.Conflicts.IgnoreName)
+	})
+
+	t.Run("should fail on missing file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+
+		assert.ErrorContains(t, err, "failed to read config file")
+	})
+}
@@ -1,12 +1,227 @@
 package dto
 
+import (
+	"hotel-data-merge/pkg/geo"
+	"time"
+)
+
+// ListHotelsRequest filters the hotels. Each list matches any of its values, and the lists are combined by Match.
+// The hotels are ordered by Sort, then by hotel id, and Limit and Cursor page through them
 type ListHotelsRequest struct {
+	Query          string // only hotels with every word of the query in their name, description, address or amenities
 	HotelIDs       []string
 	DestinationIDs []string
+	Amenities      []string // matched by their canonical name, so "pool" matches "outdoor pool"
+	AmenityMatch   string   // whether hotels need all or any of the amenities, all by default
+	Countries      []string
+	Cities         []string
+	Match          string
+	Sort           string
+	Order          string
+	Origin         *geo.Point // the point distances are measured from, Near if not set
+	Near           *geo.Point // only hotels within RadiusKm of this point
+	RadiusKm       float64
+	BoundingBox    *geo.BoundingBox // only hotels within this box
+	Limit          int
+	Cursor         string
+	Facets         []string // facets to count over the hotels matching the filters
+	Include        []string // extra details to return with the hotels of the page, only provenance
 }
 
+const (
+	// MatchAll returns the hotels matching every filter, the default
+	MatchAll = "all"
+	// MatchAny returns the hotels matching at least one filter
+	MatchAny = "any"
+)
+
+const (
+	SortName          = "name"
+	SortDestinationID = "destination_id"
+	// SortCompleteness orders hotels by how many of their fields have data
+	SortCompleteness = "completeness"
+	// SortDistance orders hotels by their distance from the origin. Hotels without coordinates are always last
+	SortDistance = "distance"
+	// SortRelevance orders hotels by how well they match the query, the default sort when there is a query
+	SortRelevance = "relevance"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
 type ListHotelsResponse struct {
-	Data []Hotel `json:"data"`
+	Data       []Hotel `json:"data"`
+	Total      int     `json:"total"`                 // number of hotels matching the filters across all pages
+	NextCursor string  `json:"next_cursor,omitempty"` // empty on the last page
+	// Provenance holds the provenance of every hotel of the page by hotel id, only set when the provenance is included
+	Provenance map[string]Provenance `json:"provenance,omitempty"`
+	Meta       *ResponseMeta         `json:"meta,omitempty"`
+}
+
+type GetHotelRequest struct {
+	HotelID string
+	Include []string // extra details to return with the merged hotel
+}
+
+const (
+	// IncludeSources returns the hotel of every supplier as it was normalized, before it was merged
+	IncludeSources = "sources"
+	// IncludeProvenance returns the supplier, raw value and merge rule of every merged field
+	IncludeProvenance = "provenance"
+)
+
+type GetHotelResponse struct {
+	Data       Hotel           `json:"data"`
+	Sources    []SupplierHotel `json:"sources,omitempty"`    // only set when the sources are included
+	Provenance Provenance      `json:"provenance,omitempty"` // only set when the provenance is included
+	Meta       *ResponseMeta   `json:"meta,omitempty"`
+}
+
+// Provenance lists, by merged field, the suppliers its value came from
+type Provenance map[string][]FieldProvenance
+
+type FieldProvenance struct {
+	Supplier string      `json:"supplier"`
+	Value    interface{} `json:"value"` // the value of the supplier before it was cleaned
+	Rule     string      `json:"rule"`  // the merge rule that selected the value
+}
+
+// SupplierHotel is the hotel of a single supplier, normalized but not cleaned or merged
+type SupplierHotel struct {
+	Supplier string      `json:"supplier"`
+	Hotel    SourceHotel `json:"hotel"`
+}
+
+type SourceHotel struct {
+	HotelID           string         `json:"hotel_id"`
+	DestinationID     int32          `json:"destination_id"`
+	Name              string         `json:"name"`
+	Location          *HotelLocation `json:"location,omitempty"`
+	Description       string         `json:"description,omitempty"`
+	Amenities         []string       `json:"amenities,omitempty"`
+	Images            *HotelImages   `json:"images,omitempty"`
+	BookingConditions []string       `json:"booking_conditions,omitempty"`
+}
+
+type SuggestRequest struct {
+	Prefix string
+	Limit  int
+}
+
+type SuggestResponse struct {
+	Data []Suggestion `json:"data"`
+}
+
+const (
+	SuggestionTypeHotel = "hotel"
+	SuggestionTypeCity  = "city"
+)
+
+type Suggestion struct {
+	Text    string `json:"text"`
+	Type    string `json:"type"`
+	HotelID string `json:"hotel_id,omitempty"` // only set for hotel names
+	Count   int    `json:"count,omitempty"`    // number of hotels in the city, only set for cities
+}
+
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ResponseMeta struct {
+	Partial bool         `json:"partial"` // true if any of the sources failed and its hotels are missing
+	Sources []SourceMeta `json:"sources"`
+	Facets  *Facets      `json:"facets,omitempty"`
+}
+
+const (
+	FacetAmenities     = "amenities"
+	FacetCountry       = "country"
+	FacetCity          = "city"
+	FacetDestinationID = "destination_id"
+)
+
+// Facets counts the hotels matching the filters by each value of the requested facets, most common value first
+type Facets struct {
+	GeneralAmenities []FacetCount `json:"general_amenities,omitempty"`
+	RoomAmenities    []FacetCount `json:"room_amenities,omitempty"`
+	Countries        []FacetCount `json:"countries,omitempty"`
+	Cities           []FacetCount `json:"cities,omitempty"`
+	DestinationIDs   []FacetCount `json:"destination_ids,omitempty"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+const (
+	SourceStatusOK    = "ok"
+	SourceStatusError = "error"
+	// SourceStatusStale means the source failed and its last successful data is returned instead
+	SourceStatusStale = "stale"
+)
+
+type SourceMeta struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	LatencyMs   int64  `json:"latency_ms"`
+	HTTPStatus  int    `json:"http_status,omitempty"`
+	Attempts    int    `json:"attempts,omitempty"`
+	NotModified bool   `json:"not_modified,omitempty"`
+	RecordCount int    `json:"record_count"`
+	// StaleAgeSeconds is how old the returned data of a stale source is
+	StaleAgeSeconds int64 `json:"stale_age_seconds,omitempty"`
+}
+
+const (
+	ConflictCoordinates   = "coordinates"
+	ConflictName          = "name"
+	ConflictCountry       = "country"
+	ConflictDestinationID = "destination_id"
+)
+
+type ConflictsRequest struct {
+	Type string // only conflicts of this type, all if empty
+}
+
+type ConflictsResponse struct {
+	Data  []HotelConflicts `json:"data"`
+	Total int              `json:"total"` // number of hotels with conflicts
+}
+
+// HotelConflicts lists the fields the suppliers of a hotel disagree on
+type HotelConflicts struct {
+	HotelID   string     `json:"hotel_id"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+type Conflict struct {
+	Type    string          `json:"type"`
+	Message string          `json:"message"`
+	Values  []ConflictValue `json:"values"` // the value of every supplier with one
+}
+
+type ConflictValue struct {
+	Supplier string      `json:"supplier"`
+	Value    interface{} `json:"value"`
+}
+
+type HealthResponse struct {
+	Suppliers []SupplierHealth `json:"suppliers"`
+}
+
+type SupplierHealth struct {
+	Name                string     `json:"name"`
+	CircuitState        string     `json:"circuit_state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
 }
 
 type Hotel struct {
@@ -18,6 +233,7 @@ type Hotel struct {
 	Amenities         *HotelAmenity  `json:"amenities,omitempty"`
 	Images            *HotelImages   `json:"images,omitempty"`
 	BookingConditions []string       `json:"booking_conditions,omitempty"`
+	DistanceKm        *float64       `json:"distance_km,omitempty"` // only set when the request has a point to measure from
 }
 
 type HotelImages struct {
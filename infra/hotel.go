@@ -2,19 +2,24 @@ package infra
 
 import (
 	"context"
+	"fmt"
+	"hotel-data-merge/config"
+	"hotel-data-merge/pkg/breaker"
 	"hotel-data-merge/usecase"
+	"log"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 )
 
-const (
-	url = "https://5f2be0b4ffc88500167b85a0.mockapi.io/suppliers/"
-)
-
 type HotelSourceConfig struct {
 	name         string
+	priority     int
+	endpoint     string
+	timeout      time.Duration
 	hotelFetcher HotelFetcher
+	breaker      *breaker.Breaker
 }
 
 type HotelRepo struct {
@@ -22,52 +27,132 @@ type HotelRepo struct {
 	hotelSourceConfigs []HotelSourceConfig
 }
 
-func NewHotelRepo(client *http.Client) usecase.HotelRepository {
+// NewHotelRepo builds the repository from the supplier configs. An error is returned if any supplier, enabled or not,
+// uses an unknown format or an invalid mapping, and disabled suppliers are then skipped
+func NewHotelRepo(client *http.Client, suppliers []config.SupplierConfig) (usecase.HotelRepository, error) {
 	if client == nil {
 		client = &http.Client{}
 	}
 
-	return &HotelRepo{
-		httpClient: client,
-		hotelSourceConfigs: []HotelSourceConfig{
-			{
-				name:         usecase.Patagonia,
-				hotelFetcher: PatagoniaFetcher{},
-			},
-			{
-				name:         usecase.Paperflies,
-				hotelFetcher: PaperfliesFetcher{},
-			},
-			{
-				name:         usecase.Acme,
-				hotelFetcher: AcmeFetcher{},
-			},
-		},
+	mappings := make([]config.MappingConfig, len(suppliers))
+	for i, supplier := range suppliers {
+		mapping, err := mappingForSupplier(supplier)
+		if err != nil {
+			return nil, fmt.Errorf("supplier %s: %v", supplier.Name, err)
+		}
+		mappings[i] = mapping
 	}
+
+	hotelSourceConfigs := []HotelSourceConfig{}
+	for i, supplier := range suppliers {
+		if !supplier.Enabled {
+			continue
+		}
+
+		hotelSourceConfigs = append(hotelSourceConfigs, HotelSourceConfig{
+			name:         supplier.Name,
+			priority:     supplier.Priority,
+			endpoint:     supplier.URL,
+			timeout:      supplier.Timeout,
+			hotelFetcher: NewMappingFetcher(mappings[i], NewRetryPolicy(supplier.Retry)),
+			breaker:      breaker.New(supplier.CircuitBreaker.FailureThreshold, supplier.CircuitBreaker.CoolDown),
+		})
+	}
+
+	sort.SliceStable(hotelSourceConfigs, func(i, j int) bool {
+		return hotelSourceConfigs[i].priority < hotelSourceConfigs[j].priority
+	})
+
+	return &HotelRepo{
+		httpClient:         client,
+		hotelSourceConfigs: hotelSourceConfigs,
+	}, nil
 }
 
-func (hr *HotelRepo) ListHotels(ctx context.Context) map[string][]usecase.Hotel {
-	hotels := map[string][]usecase.Hotel{}
+// ListHotels fetches all suppliers in parallel and returns the outcome of each supplier in priority order
+func (hr *HotelRepo) ListHotels(ctx context.Context) []usecase.SupplierResult {
+	results := make([]usecase.SupplierResult, len(hr.hotelSourceConfigs))
 	var wg sync.WaitGroup
-	var mutex sync.Mutex
 
-	for _, config := range hr.hotelSourceConfigs {
+	for i, source := range hr.hotelSourceConfigs {
 		wg.Add(1)
-		go func(config HotelSourceConfig) {
+		go func(i int, source HotelSourceConfig) {
 			defer wg.Done()
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer cancel()
-			normalizedHotels, err := config.hotelFetcher.GetHotels(ctx, hr.httpClient, config.name)
-			if err != nil {
-				return
-			}
-
-			mutex.Lock()
-			hotels[config.name] = normalizedHotels
-			mutex.Unlock()
-		}(config)
+			results[i] = hr.fetchSupplier(source)
+		}(i, source)
 	}
 	wg.Wait()
 
-	return hotels
+	return results
+}
+
+// FetchSupplier fetches a single supplier by name
+func (hr *HotelRepo) FetchSupplier(ctx context.Context, name string) usecase.SupplierResult {
+	for _, source := range hr.hotelSourceConfigs {
+		if source.name == name {
+			return hr.fetchSupplier(source)
+		}
+	}
+
+	return usecase.SupplierResult{
+		Name: name,
+		Err:  fmt.Errorf("unknown supplier %s", name),
+	}
+}
+
+// fetchSupplier fetches a single supplier through its circuit breaker. An open circuit fails immediately
+// so the caller can fall back to previous data instead of waiting for the timeout
+func (hr *HotelRepo) fetchSupplier(source HotelSourceConfig) usecase.SupplierResult {
+	if !source.breaker.Allow() {
+		return usecase.SupplierResult{
+			Name:     source.name,
+			Priority: source.priority,
+			Err:      fmt.Errorf("skipped fetching supplier %s: %w", source.name, breaker.ErrOpen),
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), source.timeout)
+	defer cancel()
+
+	start := time.Now()
+	fetchResult, err := source.hotelFetcher.GetHotels(ctx, hr.httpClient, source.endpoint)
+	result := usecase.SupplierResult{
+		Name:        source.name,
+		Priority:    source.priority,
+		Latency:     time.Since(start),
+		StatusCode:  fetchResult.StatusCode,
+		Attempts:    fetchResult.Attempts,
+		NotModified: fetchResult.NotModified,
+		FetchedAt:   time.Now(),
+	}
+
+	if err != nil {
+		log.Printf("failed to fetch hotels from supplier %s: %v", source.name, err)
+		source.breaker.Failure()
+		result.Err = err
+		return result
+	}
+
+	source.breaker.Success()
+	// a supplier that did not change keeps the time of the fetch its hotels are from, so it does not win most_recent
+	result.FetchedAt = fetchResult.FetchedAt
+	result.Hotels = fetchResult.Hotels
+	result.RecordCount = len(fetchResult.Hotels)
+	return result
+}
+
+// SupplierHealth returns the circuit breaker state of every supplier
+func (hr *HotelRepo) SupplierHealth() []usecase.SupplierHealth {
+	health := []usecase.SupplierHealth{}
+	for _, source := range hr.hotelSourceConfigs {
+		status := source.breaker.Status()
+		health = append(health, usecase.SupplierHealth{
+			Name:                source.name,
+			CircuitState:        string(status.State),
+			ConsecutiveFailures: status.ConsecutiveFailures,
+			OpenedAt:            status.OpenedAt,
+		})
+	}
+
+	return health
 }
@@ -0,0 +1,172 @@
+package infra
+
+import (
+	"fmt"
+	"hotel-data-merge/config"
+	"hotel-data-merge/usecase"
+)
+
+// FormatCustom is used by suppliers that bring their own mapping in the config
+const FormatCustom = "custom"
+
+// targets are the hotel fields a supplier field can be mapped to
+const (
+	TargetHotelID           = "hotel_id"
+	TargetDestinationID     = "destination_id"
+	TargetName              = "name"
+	TargetDescription       = "description"
+	TargetAddress           = "location.address"
+	TargetCity              = "location.city"
+	TargetCountry           = "location.country"
+	TargetLatitude          = "location.latitude"
+	TargetLongitude         = "location.longitude"
+	TargetAmenities         = "amenities"
+	TargetBookingConditions = "booking_conditions"
+	TargetRoomImages        = "images.rooms"
+	TargetSiteImages        = "images.site"
+	TargetAmenityImages     = "images.amenities"
+)
+
+// transforms that can be applied to the source values before they are set on the hotel
+const (
+	// TransformNone takes the first source that has a value, or every source for list targets
+	TransformNone = ""
+	// TransformConcat joins all source values with the separator, skipping values that are already part of the result
+	TransformConcat = "concat"
+	// TransformSplit splits string source values with the separator, for list targets
+	TransformSplit = "split"
+)
+
+type targetKind int
+
+const (
+	stringTarget targetKind = iota
+	intTarget
+	floatTarget
+	listTarget
+	imageTarget
+)
+
+var targetKinds = map[string]targetKind{
+	TargetHotelID:           stringTarget,
+	TargetDestinationID:     intTarget,
+	TargetName:              stringTarget,
+	TargetDescription:       stringTarget,
+	TargetAddress:           stringTarget,
+	TargetCity:              stringTarget,
+	TargetCountry:           stringTarget,
+	TargetLatitude:          floatTarget,
+	TargetLongitude:         floatTarget,
+	TargetAmenities:         listTarget,
+	TargetBookingConditions: listTarget,
+	TargetRoomImages:        imageTarget,
+	TargetSiteImages:        imageTarget,
+	TargetAmenityImages:     imageTarget,
+}
+
+// builtinMappings are the mappings of the suppliers we started with. They can be referred to by format in the config
+var builtinMappings = map[string]config.MappingConfig{
+	usecase.Paperflies: {
+		Fields: []config.FieldMapping{
+			{Target: TargetHotelID, Source: "hotel_id"},
+			{Target: TargetDestinationID, Source: "destination_id"},
+			{Target: TargetName, Source: "hotel_name"},
+			{Target: TargetDescription, Source: "details"},
+			{Target: TargetAddress, Source: "location.address"},
+			{Target: TargetCountry, Source: "location.country"},
+			{Target: TargetAmenities, Sources: []string{"amenities.general", "amenities.room"}},
+			{Target: TargetRoomImages, Source: "images.rooms", Link: "link", Description: "caption"},
+			{Target: TargetSiteImages, Source: "images.site", Link: "link", Description: "caption"},
+			{Target: TargetBookingConditions, Source: "booking_conditions"},
+		},
+	},
+	usecase.Patagonia: {
+		Fields: []config.FieldMapping{
+			{Target: TargetHotelID, Source: "id"},
+			{Target: TargetDestinationID, Source: "destination"},
+			{Target: TargetName, Source: "name"},
+			{Target: TargetDescription, Source: "info"},
+			{Target: TargetAddress, Source: "address"},
+			{Target: TargetLatitude, Source: "lat"},
+			{Target: TargetLongitude, Source: "lng"},
+			{Target: TargetAmenities, Source: "amenities"},
+			{Target: TargetRoomImages, Source: "images.rooms", Link: "url", Description: "description"},
+			{Target: TargetAmenityImages, Source: "images.amenities", Link: "url", Description: "description"},
+		},
+	},
+	usecase.Acme: {
+		Fields: []config.FieldMapping{
+			{Target: TargetHotelID, Source: "Id"},
+			{Target: TargetDestinationID, Source: "DestinationId"},
+			{Target: TargetName, Source: "Name"},
+			{Target: TargetDescription, Source: "Description"},
+			{Target: TargetAddress, Sources: []string{"Address", "PostalCode"}, Transform: TransformConcat, Separator: ", "},
+			{Target: TargetCity, Source: "City"},
+			{Target: TargetCountry, Source: "Country"},
+			{Target: TargetLatitude, Source: "Latitude"},
+			{Target: TargetLongitude, Source: "Longitude"},
+			{Target: TargetAmenities, Source: "Facilities"},
+		},
+	},
+}
+
+// mappingForSupplier returns the mapping of a supplier, either its own mapping or the builtin mapping of its format
+func mappingForSupplier(supplier config.SupplierConfig) (config.MappingConfig, error) {
+	if supplier.Mapping != nil {
+		return *supplier.Mapping, validateMapping(*supplier.Mapping)
+	}
+
+	if supplier.Format == FormatCustom {
+		return config.MappingConfig{}, fmt.Errorf("format %q requires a mapping", FormatCustom)
+	}
+
+	mapping, exists := builtinMappings[supplier.Format]
+	if !exists {
+		return config.MappingConfig{}, fmt.Errorf("unknown format %q", supplier.Format)
+	}
+
+	return mapping, nil
+}
+
+func validateMapping(mapping config.MappingConfig) error {
+	hasHotelID := false
+
+	for i, field := range mapping.Fields {
+		kind, exists := targetKinds[field.Target]
+		if !exists {
+			return fmt.Errorf("mapping field %d has unknown target %q", i, field.Target)
+		}
+
+		if len(field.Paths()) == 0 {
+			return fmt.Errorf("mapping field %s has no source", field.Target)
+		}
+
+		switch field.Transform {
+		case TransformNone:
+		case TransformConcat:
+			if kind != stringTarget {
+				return fmt.Errorf("mapping field %s: transform %q only applies to string targets", field.Target, field.Transform)
+			}
+		case TransformSplit:
+			if kind != listTarget {
+				return fmt.Errorf("mapping field %s: transform %q only applies to list targets", field.Target, field.Transform)
+			}
+		default:
+			return fmt.Errorf("mapping field %s has unknown transform %q", field.Target, field.Transform)
+		}
+
+		if kind == imageTarget && field.Link == "" {
+			return fmt.Errorf("mapping field %s requires a link path", field.Target)
+		}
+
+		if field.Target == TargetHotelID {
+			hasHotelID = true
+		}
+	}
+
+	if !hasHotelID {
+		return fmt.Errorf("mapping has no %s target", TargetHotelID)
+	}
+
+	return nil
+}
@@ -3,203 +3,328 @@ package infra
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hotel-data-merge/config"
 	"hotel-data-merge/usecase"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type HotelFetcher interface {
-	GetHotels(ctx context.Context, httpClient *http.Client, name string) ([]usecase.Hotel, error)
+	GetHotels(ctx context.Context, httpClient *http.Client, endpoint string) (FetchResult, error)
 }
 
-type PaperfliesFetcher struct{}
+// FetchResult holds the normalized hotels and details about the supplier response
+type FetchResult struct {
+	Hotels      []usecase.Hotel
+	StatusCode  int
+	Attempts    int
+	NotModified bool      // true if the supplier data did not change and the previous hotels are returned
+	FetchedAt   time.Time // when the hotels were fetched, which is the previous fetch when not modified
+}
+
+// MappingFetcher fetches hotels from a supplier and normalizes them using the supplier's mapping.
+// It keeps the ETag and Last-Modified of the last response to send conditional requests
+type MappingFetcher struct {
+	mapping     config.MappingConfig
+	retryPolicy RetryPolicy
+
+	mutex        sync.Mutex
+	etag         string
+	lastModified string
+	hotels       []usecase.Hotel
+	fetchedAt    time.Time
+}
+
+func NewMappingFetcher(mapping config.MappingConfig, retryPolicy RetryPolicy) *MappingFetcher {
+	return &MappingFetcher{
+		mapping:     mapping,
+		retryPolicy: retryPolicy,
+	}
+}
 
-func (n PaperfliesFetcher) GetHotels(ctx context.Context, httpClient *http.Client, name string) ([]usecase.Hotel, error) {
-	endpoint := fmt.Sprintf("%s%s", url, name)
+func (n *MappingFetcher) GetHotels(ctx context.Context, httpClient *http.Client, endpoint string) (FetchResult, error) {
+	result := FetchResult{}
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
-		return nil, err
+		return result, err
 	}
-	req = req.WithContext(ctx)
-	resp, err := httpClient.Do(req)
+
+	n.mutex.Lock()
+	etag, lastModified, previousHotels, previousFetchedAt := n.etag, n.lastModified, n.hotels, n.fetchedAt
+	n.mutex.Unlock()
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, attempts, err := n.retryPolicy.Do(ctx, httpClient, req)
+	result.Attempts = attempts
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch data from %s: %v", endpoint, err)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			result.StatusCode = statusErr.StatusCode
+		}
+		return result, fmt.Errorf("failed to fetch data from %s after %d attempts: %v", endpoint, attempts, err)
 	}
 	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+
+	if resp.StatusCode == http.StatusNotModified {
+		if etag == "" && lastModified == "" {
+			return result, fmt.Errorf("unexpected status code %d without a conditional request", resp.StatusCode)
+		}
 
-	var data []usecase.PaperfliesHotel
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+		result.Hotels = previousHotels
+		result.FetchedAt = previousFetchedAt
+		result.NotModified = true
+		return result, nil
 	}
 
-	var hotels []usecase.Hotel
-	for _, h := range data {
-		hotel := normalizePaperfliesHotel(h)
-		hotels = append(hotels, hotel)
+	result.FetchedAt = time.Now()
+	var data interface{}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return result, fmt.Errorf("failed to decode response: %v", err)
 	}
-	return hotels, nil
-}
 
-func normalizePaperfliesHotel(h usecase.PaperfliesHotel) usecase.Hotel {
-	hotel := usecase.Hotel{
-		HotelID:           h.HotelID,
-		DestinationID:     h.DestinationID,
-		Name:              h.HotelName,
-		Description:       h.Details,
-		BookingConditions: h.BookingConditions,
+	if n.mapping.Root != "" {
+		data = lookupPath(data, n.mapping.Root)
 	}
 
-	if h.Location != nil {
-		hotel.Location = &usecase.HotelLocation{
-			Address: h.Location.Address,
-			Country: h.Location.Country,
-		}
+	records, ok := data.([]interface{})
+	if !ok {
+		return result, fmt.Errorf("failed to decode response: expected a list of hotels")
 	}
 
-	if h.Amenities != nil {
-		hotel.Amenities = append(h.Amenities.GeneralAmenity, h.Amenities.RoomAmenity...)
+	for _, record := range records {
+		hotel := normalizeHotel(n.mapping, record)
+		result.Hotels = append(result.Hotels, hotel)
 	}
 
-	if h.Images != nil {
-		hotel.Images = &usecase.HotelImages{}
+	n.mutex.Lock()
+	n.etag = resp.Header.Get("ETag")
+	n.lastModified = resp.Header.Get("Last-Modified")
+	n.hotels = result.Hotels
+	n.fetchedAt = result.FetchedAt
+	n.mutex.Unlock()
 
-		for _, image := range h.Images.RoomImages {
-			hotel.Images.RoomImages = append(hotel.Images.RoomImages, usecase.HotelImage{
-				Link:        image.Link,
-				Description: image.Caption,
-			})
+	return result, nil
+}
+
+// normalizeHotel maps a single supplier record to a hotel using the mapping
+func normalizeHotel(mapping config.MappingConfig, record interface{}) usecase.Hotel {
+	hotel := usecase.Hotel{
+		Location: &usecase.HotelLocation{},
+	}
+
+	for _, field := range mapping.Fields {
+		values := []interface{}{}
+		for _, path := range field.Paths() {
+			values = append(values, lookupPath(record, path))
 		}
 
-		for _, image := range h.Images.SiteImages {
-			hotel.Images.SiteImages = append(hotel.Images.SiteImages, usecase.HotelImage{
-				Link:        image.Link,
-				Description: image.Caption,
-			})
+		switch field.Target {
+		case TargetHotelID:
+			hotel.HotelID = stringValue(field, values)
+		case TargetDestinationID:
+			hotel.DestinationID = int32Parser(firstValue(values))
+		case TargetName:
+			hotel.Name = stringValue(field, values)
+		case TargetDescription:
+			hotel.Description = stringValue(field, values)
+		case TargetAddress:
+			hotel.Location.Address = stringPointerValue(field, values)
+		case TargetCity:
+			hotel.Location.City = stringPointerValue(field, values)
+		case TargetCountry:
+			hotel.Location.Country = stringPointerValue(field, values)
+		case TargetLatitude:
+			hotel.Location.Latitude = float32Parser(firstValue(values))
+		case TargetLongitude:
+			hotel.Location.Longitude = float32Parser(firstValue(values))
+		case TargetAmenities:
+			hotel.Amenities = append(hotel.Amenities, listValue(field, values)...)
+		case TargetBookingConditions:
+			hotel.BookingConditions = append(hotel.BookingConditions, listValue(field, values)...)
+		case TargetRoomImages, TargetSiteImages, TargetAmenityImages:
+			normalizeImages(&hotel, field, values)
 		}
 	}
 
 	return hotel
 }
 
-type PatagoniaFetcher struct{}
+func normalizeImages(hotel *usecase.Hotel, field config.FieldMapping, values []interface{}) {
+	images := []usecase.HotelImage{}
+	found := false
 
-func (n PatagoniaFetcher) GetHotels(ctx context.Context, httpClient *http.Client, name string) ([]usecase.Hotel, error) {
-	endpoint := fmt.Sprintf("%s%s", url, name)
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
+	for _, value := range values {
+		items, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+		found = true
+
+		for _, item := range items {
+			images = append(images, usecase.HotelImage{
+				Link:        stringParser(lookupPath(item, field.Link)),
+				Description: stringParser(lookupPath(item, field.Description)),
+			})
+		}
 	}
-	req = req.WithContext(ctx)
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch data from %s: %v", endpoint, err)
+
+	// images are only set if the supplier provides them, same as the other fields
+	if !found {
+		return
 	}
 
-	var data []usecase.PatagoniaHotel
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	if hotel.Images == nil {
+		hotel.Images = &usecase.HotelImages{}
 	}
 
-	var hotels []usecase.Hotel
-	for _, h := range data {
-		hotel := normalizePatagoniaHotel(h)
-		hotels = append(hotels, hotel)
+	if len(images) == 0 {
+		return
+	}
+
+	switch field.Target {
+	case TargetRoomImages:
+		hotel.Images.RoomImages = append(hotel.Images.RoomImages, images...)
+	case TargetSiteImages:
+		hotel.Images.SiteImages = append(hotel.Images.SiteImages, images...)
+	case TargetAmenityImages:
+		hotel.Images.AmmenityImages = append(hotel.Images.AmmenityImages, images...)
 	}
-	return hotels, nil
 }
 
-func normalizePatagoniaHotel(h usecase.PatagoniaHotel) usecase.Hotel {
-	hotel := usecase.Hotel{
-		HotelID:       h.HotelID,
-		DestinationID: h.DestinationID,
-		Name:          h.HotelName,
-		Description:   h.Info,
-		Location: &usecase.HotelLocation{
-			Address:   h.Address,
-			Latitude:  h.Latitude,
-			Longitude: h.Longitude,
-		},
-		Amenities: h.Amenities,
-	}
-
-	if h.Images != nil {
-		hotel.Images = &usecase.HotelImages{}
+// lookupPath walks the dot separated path through the decoded json, returning nil if any part is missing
+func lookupPath(data interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
 
-		for _, image := range h.Images.RoomImages {
-			hotel.Images.RoomImages = append(hotel.Images.RoomImages, usecase.HotelImage{
-				Link:        image.Url,
-				Description: image.Description,
-			})
+	current := data
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
 		}
+		current = object[key]
+	}
 
-		for _, image := range h.Images.AmenityImages {
-			hotel.Images.AmmenityImages = append(hotel.Images.AmmenityImages, usecase.HotelImage{
-				Link:        image.Url,
-				Description: image.Description,
-			})
+	return current
+}
+
+func firstValue(values []interface{}) interface{} {
+	for _, value := range values {
+		if value != nil {
+			return value
 		}
 	}
 
-	return hotel
+	return nil
 }
 
-type AcmeFetcher struct{}
-
-func (n AcmeFetcher) GetHotels(ctx context.Context, httpClient *http.Client, name string) ([]usecase.Hotel, error) {
-	endpoint := fmt.Sprintf("%s%s", url, name)
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
+func stringValue(field config.FieldMapping, values []interface{}) string {
+	val := stringPointerValue(field, values)
+	if val == nil {
+		return ""
 	}
-	req = req.WithContext(ctx)
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch data from %s: %v", endpoint, err)
-	}
-	defer resp.Body.Close()
 
-	var data []usecase.AcmeHotel
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	return *val
+}
+
+func stringPointerValue(field config.FieldMapping, values []interface{}) *string {
+	if field.Transform != TransformConcat {
+		value := firstValue(values)
+		if value == nil {
+			return nil
+		}
+
+		val := stringParser(value)
+		return &val
 	}
 
-	var hotels []usecase.Hotel
-	for _, h := range data {
-		hotel := normalizeAcmeHotel(h)
-		hotels = append(hotels, hotel)
+	var result *string
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+
+		val := stringParser(value)
+		if result == nil {
+			result = &val
+			continue
+		}
+
+		if strings.Contains(*result, val) {
+			continue
+		}
+
+		concatenated := fmt.Sprintf("%s%s%s", strings.TrimSpace(*result), field.Separator, strings.TrimSpace(val))
+		result = &concatenated
 	}
-	return hotels, nil
-}
 
-func normalizeAcmeHotel(h usecase.AcmeHotel) usecase.Hotel {
-	address := h.Address
+	return result
+}
 
-	if h.Address != nil && h.Postcode != nil && !strings.Contains(*h.Address, *h.Postcode) {
-		add := fmt.Sprintf("%s, %s", strings.TrimSpace(*h.Address), strings.TrimSpace(*h.Postcode))
-		address = &add
+func listValue(field config.FieldMapping, values []interface{}) []string {
+	var list []string
+
+	for _, value := range values {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				if item != nil {
+					list = append(list, stringParser(item))
+				}
+			}
+		case nil:
+		default:
+			if field.Transform != TransformSplit {
+				list = append(list, stringParser(v))
+				continue
+			}
+
+			for _, item := range strings.Split(stringParser(v), field.Separator) {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+		}
 	}
 
-	lat := float32Parser(h.Latitude)
-	lng := float32Parser(h.Longitude)
+	return list
+}
 
-	hotel := usecase.Hotel{
-		HotelID:       h.HotelID,
-		DestinationID: h.DestinationID,
-		Name:          h.HotelName,
-		Description:   h.Description,
-		Location: &usecase.HotelLocation{
-			Address:   address,
-			City:      h.City,
-			Country:   h.Country,
-			Latitude:  lat,
-			Longitude: lng,
-		},
-		Amenities: h.Facilities,
+func stringParser(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
 	}
+}
 
-	return hotel
+func int32Parser(value interface{}) int32 {
+	val, err := strconv.ParseInt(strings.TrimSpace(stringParser(value)), 10, 32)
+	if err != nil {
+		return 0
+	}
+
+	return int32(val)
 }
 
 func float32Parser(value interface{}) *float32 {
@@ -211,6 +336,13 @@ func float32Parser(value interface{}) *float32 {
 		}
 		float32Val := float32(val)
 		return &float32Val
+	case json.Number:
+		val, err := strconv.ParseFloat(v.String(), 32)
+		if err != nil {
+			return nil
+		}
+		float32Val := float32(val)
+		return &float32Val
 	case float32:
 		return &v
 	case float64:
@@ -4,11 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hotel-data-merge/config"
+	"hotel-data-merge/pkg/breaker"
 	"hotel-data-merge/usecase"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -27,6 +31,101 @@ func newMockClient(responseFunc func(req *http.Request) *http.Response) *http.Cl
 	}
 }
 
+// supplier response formats used to build the mock responses
+type mockPaperfliesHotel struct {
+	HotelID           string                `json:"hotel_id"`
+	DestinationID     int32                 `json:"destination_id"`
+	HotelName         string                `json:"hotel_name"`
+	Location          *mockLocation         `json:"location,omitempty"`
+	Details           string                `json:"details,omitempty"`
+	Amenities         *mockAmenities        `json:"amenities,omitempty"`
+	Images            *mockPaperfliesImages `json:"images,omitempty"`
+	BookingConditions []string              `json:"booking_conditions,omitempty"`
+}
+
+type mockLocation struct {
+	Address *string `json:"address,omitempty"`
+	Country *string `json:"country,omitempty"`
+}
+
+type mockAmenities struct {
+	GeneralAmenity []string `json:"general,omitempty"`
+	RoomAmenity    []string `json:"room,omitempty"`
+}
+
+type mockPaperfliesImages struct {
+	RoomImages []mockPaperfliesImage `json:"rooms,omitempty"`
+	SiteImages []mockPaperfliesImage `json:"site,omitempty"`
+}
+
+type mockPaperfliesImage struct {
+	Link    string `json:"link"`
+	Caption string `json:"caption"`
+}
+
+type mockPatagoniaHotel struct {
+	HotelID       string               `json:"id"`
+	DestinationID int32                `json:"destination"`
+	HotelName     string               `json:"name"`
+	Latitude      *float32             `json:"lat,omitempty"`
+	Longitude     *float32             `json:"lng,omitempty"`
+	Address       *string              `json:"address,omitempty"`
+	Info          string               `json:"info,omitempty"`
+	Amenities     []string             `json:"amenities,omitempty"`
+	Images        *mockPatagoniaImages `json:"images,omitempty"`
+}
+
+type mockPatagoniaImages struct {
+	RoomImages    []mockPatagoniaImage `json:"rooms,omitempty"`
+	AmenityImages []mockPatagoniaImage `json:"amenities,omitempty"`
+}
+
+type mockPatagoniaImage struct {
+	Url         string `json:"url"`
+	Description string `json:"description"`
+}
+
+type mockAcmeHotel struct {
+	HotelID       string      `json:"Id"`
+	DestinationID int32       `json:"DestinationId"`
+	HotelName     string      `json:"Name"`
+	Latitude      interface{} `json:"Latitude,omitempty"`
+	Longitude     interface{} `json:"Longitude,omitempty"`
+	Address       *string     `json:"Address,omitempty"`
+	City          *string     `json:"City,omitempty"`
+	Country       *string     `json:"Country,omitempty"`
+	Postcode      *string     `json:"PostalCode,omitempty"`
+	Description   string      `json:"Description,omitempty"`
+	Facilities    []string    `json:"Facilities,omitempty"`
+}
+
+func hotelsBySupplier(results []usecase.SupplierResult) map[string][]usecase.Hotel {
+	hotels := map[string][]usecase.Hotel{}
+	for _, result := range results {
+		if result.Err == nil {
+			hotels[result.Name] = result.Hotels
+		}
+	}
+
+	return hotels
+}
+
+func mockSupplierConfigs() []config.SupplierConfig {
+	suppliers := []config.SupplierConfig{}
+	for i, name := range []string{usecase.Patagonia, usecase.Paperflies, usecase.Acme} {
+		suppliers = append(suppliers, config.SupplierConfig{
+			Name:     name,
+			URL:      fmt.Sprintf("https://mock-host/suppliers/%s", name),
+			Format:   name,
+			Timeout:  time.Second,
+			Enabled:  true,
+			Priority: i,
+		})
+	}
+
+	return suppliers
+}
+
 func TestListHotels(t *testing.T) {
 	mockHotelId := "mock-hotel-id"
 	mockDestinationId := int32(1)
@@ -44,29 +143,29 @@ func TestListHotels(t *testing.T) {
 	mockCity := "mock-city"
 	mockPostcode := "mock-postcode"
 
-	mockPaperfliesHotels := []usecase.PaperfliesHotel{
+	mockPaperfliesHotels := []mockPaperfliesHotel{
 		{
 			HotelID:       mockHotelId,
 			DestinationID: mockDestinationId,
 			HotelName:     mockHotelName,
-			Location: &usecase.Location{
+			Location: &mockLocation{
 				Address: &mockAddress,
 				Country: &mockCountry,
 			},
 			Details:           mockDesc,
 			BookingConditions: mockBookingConditions,
-			Amenities: &usecase.Amenities{
+			Amenities: &mockAmenities{
 				GeneralAmenity: mockGeneralAmenities,
 				RoomAmenity:    mockRoomAmenities,
 			},
-			Images: &usecase.PaperfliesImages{
-				RoomImages: []usecase.PaperfliesImage{
+			Images: &mockPaperfliesImages{
+				RoomImages: []mockPaperfliesImage{
 					{
 						Link:    mockLink,
 						Caption: mockImgDesc,
 					},
 				},
-				SiteImages: []usecase.PaperfliesImage{
+				SiteImages: []mockPaperfliesImage{
 					{
 						Link:    mockLink,
 						Caption: mockImgDesc,
@@ -76,7 +175,7 @@ func TestListHotels(t *testing.T) {
 		},
 	}
 
-	mockPatagoniaHotels := []usecase.PatagoniaHotel{
+	mockPatagoniaHotels := []mockPatagoniaHotel{
 		{
 			HotelID:       mockHotelId,
 			DestinationID: mockDestinationId,
@@ -86,14 +185,14 @@ func TestListHotels(t *testing.T) {
 			Longitude:     &mockLongitude,
 			Info:          mockDesc,
 			Amenities:     []string{"mock-general-amenities", "mock-room-amenities"},
-			Images: &usecase.PatagoniaImages{
-				RoomImages: []usecase.PatagoniaImage{
+			Images: &mockPatagoniaImages{
+				RoomImages: []mockPatagoniaImage{
 					{
 						Url:         mockLink,
 						Description: mockImgDesc,
 					},
 				},
-				AmenityImages: []usecase.PatagoniaImage{
+				AmenityImages: []mockPatagoniaImage{
 					{
 						Url:         mockLink,
 						Description: mockImgDesc,
@@ -103,7 +202,7 @@ func TestListHotels(t *testing.T) {
 		},
 	}
 
-	mockAcmeHotels := []usecase.AcmeHotel{
+	mockAcmeHotels := []mockAcmeHotel{
 		{
 			HotelID:       mockHotelId,
 			DestinationID: mockDestinationId,
@@ -231,10 +330,331 @@ func TestListHotels(t *testing.T) {
 	mockClient := newMockClient(responseFunc)
 
 	t.Run("should successfully list and normalize hotels", func(t *testing.T) {
-		r := NewHotelRepo(mockClient)
-		hotels := r.ListHotels(context.Background())
+		r, err := NewHotelRepo(mockClient, mockSupplierConfigs())
+		assert.NoError(t, err)
+
+		results := r.ListHotels(context.Background())
+
+		assert.NotEmpty(t, results)
+		assert.Equal(t, normalizedHotels, hotelsBySupplier(results))
+		for _, result := range results {
+			assert.NoError(t, result.Err)
+			assert.Equal(t, 200, result.StatusCode)
+			assert.Equal(t, 1, result.RecordCount)
+		}
+	})
+
+	t.Run("should skip disabled suppliers", func(t *testing.T) {
+		suppliers := mockSupplierConfigs()
+		suppliers[2].Enabled = false
+
+		r, err := NewHotelRepo(mockClient, suppliers)
+		assert.NoError(t, err)
+
+		results := r.ListHotels(context.Background())
+
+		assert.Len(t, results, 2)
+		assert.NotContains(t, hotelsBySupplier(results), suppliers[2].Name)
+	})
+
+	t.Run("should report failed suppliers", func(t *testing.T) {
+		failingClient := newMockClient(func(req *http.Request) *http.Response {
+			if req.URL.Path == fmt.Sprintf("/suppliers/%s", usecase.Acme) {
+				return &http.Response{
+					StatusCode: 500,
+					Body:       ioutil.NopCloser(strings.NewReader("mock-error")),
+					Header:     make(http.Header),
+				}
+			}
+			return responseFunc(req)
+		})
+
+		r, err := NewHotelRepo(failingClient, mockSupplierConfigs())
+		assert.NoError(t, err)
+
+		results := r.ListHotels(context.Background())
+
+		assert.Len(t, results, 3)
+		assert.Equal(t, []string{usecase.Patagonia, usecase.Paperflies, usecase.Acme}, []string{results[0].Name, results[1].Name, results[2].Name})
+		assert.NoError(t, results[0].Err)
+		assert.NoError(t, results[1].Err)
+		assert.Error(t, results[2].Err)
+		assert.Equal(t, 500, results[2].StatusCode)
+		assert.Equal(t, 0, results[2].RecordCount)
+		assert.Nil(t, results[2].Hotels)
+	})
+
+	t.Run("should fail on unknown supplier format", func(t *testing.T) {
+		suppliers := mockSupplierConfigs()
+		suppliers[0].Format = "mock-format"
+
+		r, err := NewHotelRepo(mockClient, suppliers)
+
+		assert.Nil(t, r)
+		assert.ErrorContains(t, err, "unknown format")
+	})
+
+	t.Run("should fail on unknown format of a disabled supplier", func(t *testing.T) {
+		suppliers := mockSupplierConfigs()
+		suppliers[1].Enabled = false
+		suppliers[1].Format = "mock-format"
+
+		r, err := NewHotelRepo(mockClient, suppliers)
+
+		assert.Nil(t, r)
+		assert.ErrorContains(t, err, "unknown format")
+	})
+}
+
+func TestListHotelsWithCustomMapping(t *testing.T) {
+	mockResponse := `{"results": [{
+		"code": 123,
+		"dest": "5432",
+		"title": "mock-name",
+		"geo": {"lat": "1.1", "lng": 1.1},
+		"street": "mock-address ",
+		"zip": "mock-postcode",
+		"facilities": "wifi, pool,",
+		"photos": [{"src": "mock-link", "alt": "mock-img-desc"}]
+	}]}`
+
+	mockClient := newMockClient(func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(mockResponse)),
+			Header:     make(http.Header),
+		}
+	})
+
+	mockSupplier := config.SupplierConfig{
+		Name:    "mock-supplier",
+		URL:     "https://mock-host/suppliers/mock-supplier",
+		Format:  FormatCustom,
+		Timeout: time.Second,
+		Enabled: true,
+		Mapping: &config.MappingConfig{
+			Root: "results",
+			Fields: []config.FieldMapping{
+				{Target: TargetHotelID, Source: "code"},
+				{Target: TargetDestinationID, Source: "dest"},
+				{Target: TargetName, Source: "title"},
+				{Target: TargetLatitude, Source: "geo.lat"},
+				{Target: TargetLongitude, Source: "geo.lng"},
+				{Target: TargetAddress, Sources: []string{"street", "zip"}, Transform: TransformConcat, Separator: ", "},
+				{Target: TargetAmenities, Source: "facilities", Transform: TransformSplit, Separator: ","},
+				{Target: TargetSiteImages, Source: "photos", Link: "src", Description: "alt"},
+			},
+		},
+	}
+
+	t.Run("should successfully normalize hotels with a custom mapping", func(t *testing.T) {
+		r, err := NewHotelRepo(mockClient, []config.SupplierConfig{mockSupplier})
+		assert.NoError(t, err)
+
+		hotels := hotelsBySupplier(r.ListHotels(context.Background()))
+
+		mockCoordinate := float32(1.1)
+		mockAddress := "mock-address, mock-postcode"
+		assert.Equal(t, map[string][]usecase.Hotel{
+			"mock-supplier": {
+				{
+					HotelID:       "123",
+					DestinationID: 5432,
+					Name:          "mock-name",
+					Location: &usecase.HotelLocation{
+						Address:   &mockAddress,
+						Latitude:  &mockCoordinate,
+						Longitude: &mockCoordinate,
+					},
+					Amenities: []string{"wifi", "pool"},
+					Images: &usecase.HotelImages{
+						SiteImages: []usecase.HotelImage{
+							{
+								Link:        "mock-link",
+								Description: "mock-img-desc",
+							},
+						},
+					},
+				},
+			},
+		}, hotels)
+	})
+
+	t.Run("should fail on invalid mappings", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			mapping *config.MappingConfig
+			err     string
+		}{
+			{
+				name:    "missing mapping",
+				mapping: nil,
+				err:     `format "custom" requires a mapping`,
+			},
+			{
+				name:    "unknown target",
+				mapping: &config.MappingConfig{Fields: []config.FieldMapping{{Target: "mock-target", Source: "code"}}},
+				err:     `unknown target "mock-target"`,
+			},
+			{
+				name:    "unknown transform",
+				mapping: &config.MappingConfig{Fields: []config.FieldMapping{{Target: TargetHotelID, Source: "code", Transform: "mock-transform"}}},
+				err:     `unknown transform "mock-transform"`,
+			},
+			{
+				name:    "split on string target",
+				mapping: &config.MappingConfig{Fields: []config.FieldMapping{{Target: TargetHotelID, Source: "code", Transform: TransformSplit}}},
+				err:     "only applies to list targets",
+			},
+			{
+				name:    "missing hotel id",
+				mapping: &config.MappingConfig{Fields: []config.FieldMapping{{Target: TargetName, Source: "title"}}},
+				err:     "mapping has no hotel_id target",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				supplier := mockSupplier
+				supplier.Mapping = tt.mapping
+
+				r, err := NewHotelRepo(mockClient, []config.SupplierConfig{supplier})
+
+				assert.Nil(t, r)
+				assert.ErrorContains(t, err, tt.err)
+			})
+		}
+	})
+}
+
+func TestListHotelsWithCircuitBreaker(t *testing.T) {
+	calls := 0
+	mockClient := newMockClient(func(req *http.Request) *http.Response {
+		calls++
+		return &http.Response{
+			StatusCode: 500,
+			Body:       ioutil.NopCloser(strings.NewReader("mock-error")),
+			Header:     make(http.Header),
+		}
+	})
+
+	suppliers := mockSupplierConfigs()[2:]
+	suppliers[0].CircuitBreaker = config.CircuitBreakerConfig{
+		FailureThreshold: 2,
+		CoolDown:         time.Minute,
+	}
+
+	t.Run("should short circuit a supplier after consecutive failures", func(t *testing.T) {
+		r, err := NewHotelRepo(mockClient, suppliers)
+		assert.NoError(t, err)
+
+		r.ListHotels(context.Background())
+		r.ListHotels(context.Background())
+		results := r.ListHotels(context.Background())
+
+		assert.Equal(t, 2, calls)
+		assert.ErrorIs(t, results[0].Err, breaker.ErrOpen)
+
+		health := r.SupplierHealth()
+		assert.Len(t, health, 1)
+		assert.Equal(t, usecase.Acme, health[0].Name)
+		assert.Equal(t, string(breaker.Open), health[0].CircuitState)
+		assert.Equal(t, 2, health[0].ConsecutiveFailures)
+		assert.False(t, health[0].OpenedAt.IsZero())
+	})
+}
+
+func TestListHotelsWithConditionalRequests(t *testing.T) {
+	mockResponse := `[{"Id": "mock-hotel-id", "Name": "mock-name"}]`
+	mockLastModified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+
+	setupServer := func(header string, value string) (*httptest.Server, *[]string) {
+		conditionalHeaders := []string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conditionalHeaders = append(conditionalHeaders, r.Header.Get("If-None-Match")+r.Header.Get("If-Modified-Since"))
+			if r.Header.Get("If-None-Match") == value || r.Header.Get("If-Modified-Since") == value {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set(header, value)
+			w.Write([]byte(mockResponse))
+		}))
+
+		return server, &conditionalHeaders
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{name: "etag", header: "ETag", value: `"mock-etag"`},
+		{name: "last modified", header: "Last-Modified", value: mockLastModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("should reuse the previous hotels when not modified using %s", tt.name), func(t *testing.T) {
+			server, conditionalHeaders := setupServer(tt.header, tt.value)
+			defer server.Close()
+
+			suppliers := mockSupplierConfigs()[2:]
+			suppliers[0].URL = server.URL
+			r, err := NewHotelRepo(server.Client(), suppliers)
+			assert.NoError(t, err)
+
+			first := r.ListHotels(context.Background())
+			second := r.ListHotels(context.Background())
+
+			assert.Equal(t, []string{"", tt.value}, *conditionalHeaders)
+			assert.False(t, first[0].NotModified)
+			assert.Equal(t, 200, first[0].StatusCode)
+			assert.NoError(t, second[0].Err)
+			assert.True(t, second[0].NotModified)
+			assert.Equal(t, 304, second[0].StatusCode)
+			assert.Equal(t, 1, second[0].RecordCount)
+			assert.Equal(t, first[0].Hotels, second[0].Hotels)
+			assert.Equal(t, first[0].FetchedAt, second[0].FetchedAt)
+		})
+	}
+
+	t.Run("should keep the fetch time of the previous hotels when not modified", func(t *testing.T) {
+		notModifiedServer, _ := setupServer("ETag", `"mock-etag"`)
+		defer notModifiedServer.Close()
+		modifiedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(mockResponse))
+		}))
+		defer modifiedServer.Close()
+
+		notModified, modified := mockSupplierConfigs()[2], mockSupplierConfigs()[2]
+		notModified.URL = notModifiedServer.URL
+		modified.Name, modified.URL, modified.Priority = "mock-supplier", modifiedServer.URL, notModified.Priority+1
+		r, err := NewHotelRepo(notModifiedServer.Client(), []config.SupplierConfig{notModified, modified})
+		assert.NoError(t, err)
+
+		first := r.ListHotels(context.Background())
+		second := r.ListHotels(context.Background())
+
+		assert.True(t, second[0].NotModified)
+		assert.Equal(t, first[0].FetchedAt, second[0].FetchedAt)
+		assert.False(t, second[1].NotModified)
+		assert.True(t, second[1].FetchedAt.After(second[0].FetchedAt))
+	})
+
+	t.Run("should fail on not modified without a conditional request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotModified)
+		}))
+		defer server.Close()
+
+		suppliers := mockSupplierConfigs()[2:]
+		suppliers[0].URL = server.URL
+		r, err := NewHotelRepo(server.Client(), suppliers)
+		assert.NoError(t, err)
+
+		results := r.ListHotels(context.Background())
 
-		assert.NotEmpty(t, hotels)
-		assert.Equal(t, normalizedHotels, hotels)
+		assert.Error(t, results[0].Err)
+		assert.Equal(t, 304, results[0].StatusCode)
 	})
 }
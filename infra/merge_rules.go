@@ -0,0 +1,55 @@
+package infra
+
+import (
+	"hotel-data-merge/config"
+	"hotel-data-merge/usecase"
+)
+
+// customMergeStrategies are the merge strategies that can be configured by name besides the builtin ones
+var customMergeStrategies = map[string]usecase.MergeStrategy{
+	"shortest": shortest,
+}
+
+// shortest keeps the shortest text, eg. to prefer a hotel name without extra words. Other values keep the first value
+func shortest(values []usecase.FieldValue) []usecase.FieldValue {
+	best := values[0]
+	for _, value := range values[1:] {
+		text, ok := value.Value.(string)
+		bestText, bestOk := best.Value.(string)
+		if ok && bestOk && len(text) < len(bestText) {
+			best = value
+		}
+	}
+
+	return []usecase.FieldValue{best}
+}
+
+// NewMergeRules builds the merge rules of the configured fields, with the custom strategies, and the conflict thresholds
+func NewMergeRules(cfg config.MergeConfig) (usecase.MergeRules, error) {
+	fields := map[string]usecase.FieldRule{}
+	for field, f := range cfg.Fields {
+		fields[field] = usecase.FieldRule{
+			Strategy:  f.Strategy,
+			Suppliers: f.Suppliers,
+		}
+	}
+
+	rules, err := usecase.NewMergeRules(fields, customMergeStrategies)
+	if err != nil {
+		return usecase.MergeRules{}, err
+	}
+
+	// the merge rules start with the default conflict thresholds, which are only replaced if configured
+	if cfg.Conflicts.MaxDistanceKm != nil {
+		rules.Conflicts.MaxDistanceKm = *cfg.Conflicts.MaxDistanceKm
+	}
+	if cfg.Conflicts.MinNameSimilarity != nil {
+		rules.Conflicts.MinNameSimilarity = *cfg.Conflicts.MinNameSimilarity
+	}
+	rules.Conflicts.Coordinates = !cfg.Conflicts.IgnoreCoordinates
+	rules.Conflicts.Name = !cfg.Conflicts.IgnoreName
+	rules.Conflicts.Country = !cfg.Conflicts.IgnoreCountry
+	rules.Conflicts.DestinationID = !cfg.Conflicts.IgnoreDestinationID
+
+	return rules, nil
+}
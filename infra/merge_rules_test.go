@@ -0,0 +1,95 @@
+package infra
+
+import (
+	"context"
+	"hotel-data-merge/config"
+	"hotel-data-merge/dto"
+	"hotel-data-merge/pkg/cache"
+	"hotel-data-merge/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewMergeRules(t *testing.T) {
+	t.Run("should merge with a configured custom strategy", func(t *testing.T) {
+		rules, err := NewMergeRules(config.MergeConfig{
+			Fields: map[string]config.FieldMergeConfig{usecase.FieldName: {Strategy: "shortest"}},
+		})
+		assert.NoError(t, err)
+
+		repo := &usecase.MockHotelRepository{}
+		repo.On("ListHotels", mock.Anything).Return([]usecase.SupplierResult{
+			{Name: usecase.Patagonia, Priority: 0, Hotels: []usecase.Hotel{{HotelID: "mbs", Name: "Marina Bay Sands Hotel"}}},
+			{Name: usecase.Acme, Priority: 1, Hotels: []usecase.Hotel{{HotelID: "mbs", Name: "Marina Bay Sands"}}},
+		})
+		hotelUsecase := usecase.NewHotelUsecase(repo, cache.NewGoCacheWrapper(time.Minute, time.Minute), usecase.CacheConfig{
+			SoftTTL: time.Minute,
+			HardTTL: time.Minute,
+		}, rules)
+
+		resp, err := hotelUsecase.GetHotel(context.Background(), &dto.GetHotelRequest{HotelID: "mbs"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Marina Bay Sands", resp.Data.Name)
+	})
+
+	t.Run("should fail on an unknown strategy", func(t *testing.T) {
+		_, err := NewMergeRules(config.MergeConfig{
+			Fields: map[string]config.FieldMergeConfig{usecase.FieldName: {Strategy: "mock-strategy"}},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("should keep the default conflict thresholds that are not configured", func(t *testing.T) {
+		rules, err := NewMergeRules(config.MergeConfig{})
+		assert.NoError(t, err)
+		assert.Equal(t, usecase.DefaultConflictConfig(), rules.Conflicts)
+	})
+
+	t.Run("should replace the configured conflict thresholds", func(t *testing.T) {
+		maxDistanceKm := 2.5
+		rules, err := NewMergeRules(config.MergeConfig{
+			Conflicts: config.ConflictsConfig{MaxDistanceKm: &maxDistanceKm, IgnoreName: true},
+		})
+		assert.NoError(t, err)
+
+		expected := usecase.DefaultConflictConfig()
+		expected.MaxDistanceKm = maxDistanceKm
+		expected.Name = false
+		assert.Equal(t, expected, rules.Conflicts)
+	})
+}
+
+func TestShortest(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []usecase.FieldValue
+		expected string
+	}{
+		{
+			name:     "should keep the shortest text",
+			values:   []usecase.FieldValue{{Supplier: usecase.Patagonia, Value: "Marina Bay Sands Hotel"}, {Supplier: usecase.Acme, Value: "Marina Bay Sands"}},
+			expected: usecase.Acme,
+		},
+		{
+			name:     "should keep the first value on a tie",
+			values:   []usecase.FieldValue{{Supplier: usecase.Patagonia, Value: "MBS"}, {Supplier: usecase.Acme, Value: "SGP"}},
+			expected: usecase.Patagonia,
+		},
+		{
+			name:     "should keep the first value if it is not text",
+			values:   []usecase.FieldValue{{Supplier: usecase.Patagonia, Value: []string{"pool"}}, {Supplier: usecase.Acme, Value: "pool"}},
+			expected: usecase.Patagonia,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := shortest(tt.values)
+			assert.Len(t, selected, 1)
+			assert.Equal(t, tt.expected, selected[0].Supplier)
+		})
+	}
+}
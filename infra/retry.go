@@ -0,0 +1,131 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"hotel-data-merge/config"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StatusError is returned when a supplier responds with a non 2xx status
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
+// RetryPolicy retries supplier requests that fail with a network error or a retryable status code,
+// waiting an exponential backoff with jitter in between attempts
+type RetryPolicy struct {
+	maxAttempts          int
+	initialBackoff       time.Duration
+	maxBackoff           time.Duration
+	multiplier           float64
+	jitter               float64
+	retryableStatusCodes map[int]bool
+}
+
+func NewRetryPolicy(cfg config.RetryConfig) RetryPolicy {
+	retryableStatusCodes := map[int]bool{}
+	for _, code := range cfg.RetryableStatusCodes {
+		retryableStatusCodes[code] = true
+	}
+
+	jitter := 0.0
+	if cfg.Jitter != nil {
+		jitter = *cfg.Jitter
+	}
+
+	return RetryPolicy{
+		maxAttempts:          cfg.MaxAttempts,
+		initialBackoff:       cfg.InitialBackoff,
+		maxBackoff:           cfg.MaxBackoff,
+		multiplier:           cfg.Multiplier,
+		jitter:               jitter,
+		retryableStatusCodes: retryableStatusCodes,
+	}
+}
+
+// Do sends the request until it gets a 2xx or 304 response, a non retryable error, or runs out of attempts.
+// It gives up early if the next wait would go past the ctx deadline. Other responses are returned as a *StatusError
+func (p RetryPolicy) Do(ctx context.Context, httpClient *http.Client, req *http.Request) (*http.Response, int, error) {
+	var lastErr error
+
+	for attempt := 1; ; attempt++ {
+		resp, err := httpClient.Do(req.WithContext(ctx))
+		retryAfter := time.Duration(0)
+
+		switch {
+		case err != nil:
+			lastErr = err
+		case resp.StatusCode >= 200 && resp.StatusCode < 300, resp.StatusCode == http.StatusNotModified:
+			return resp, attempt, nil
+		default:
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			lastErr = &StatusError{StatusCode: resp.StatusCode}
+			if !p.retryableStatusCodes[resp.StatusCode] {
+				return nil, attempt, lastErr
+			}
+		}
+
+		if attempt >= p.maxAttempts || ctx.Err() != nil {
+			return nil, attempt, lastErr
+		}
+
+		wait := p.backoff(attempt)
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return nil, attempt, lastErr
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, attempt, lastErr
+		case <-time.After(wait):
+		}
+	}
+}
+
+// backoff returns how long to wait after the given attempt, randomized by the jitter so suppliers are not hit in sync
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := float64(p.initialBackoff) * math.Pow(p.multiplier, float64(attempt-1))
+	if backoff > float64(p.maxBackoff) {
+		backoff = float64(p.maxBackoff)
+	}
+
+	backoff = backoff * (1 - p.jitter + rand.Float64()*2*p.jitter)
+	return time.Duration(backoff)
+}
+
+// parseRetryAfter parses the Retry-After header, which is either in seconds or a http date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,168 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"hotel-data-merge/config"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFlakyClient(responses ...*http.Response) (*http.Client, *int32) {
+	calls := int32(0)
+	client := newMockClient(func(req *http.Request) *http.Response {
+		call := atomic.AddInt32(&calls, 1)
+		if int(call) > len(responses) {
+			return responses[len(responses)-1]
+		}
+		return responses[call-1]
+	})
+
+	return client, &calls
+}
+
+func mockStatusResponse(statusCode int, body string, header http.Header) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		StatusCode: statusCode,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Header:     header,
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	mockJitter := 0.2
+	mockRetryConfig := config.RetryConfig{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Millisecond,
+		MaxBackoff:           5 * time.Millisecond,
+		Multiplier:           2,
+		Jitter:               &mockJitter,
+		RetryableStatusCodes: []int{429, 502, 503, 504},
+	}
+
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest("GET", "https://mock-host/suppliers/mock-supplier", nil)
+		return req
+	}
+
+	t.Run("should retry retryable status codes until success", func(t *testing.T) {
+		client, calls := newFlakyClient(
+			mockStatusResponse(503, "", nil),
+			mockStatusResponse(502, "", nil),
+			mockStatusResponse(200, "[]", nil),
+		)
+
+		resp, attempts, err := NewRetryPolicy(mockRetryConfig).Do(context.Background(), client, newRequest())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, int32(3), *calls)
+	})
+
+	t.Run("should stop after max attempts", func(t *testing.T) {
+		client, calls := newFlakyClient(mockStatusResponse(504, "", nil))
+
+		resp, attempts, err := NewRetryPolicy(mockRetryConfig).Do(context.Background(), client, newRequest())
+
+		var statusErr *StatusError
+		assert.Nil(t, resp)
+		assert.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, 504, statusErr.StatusCode)
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, int32(3), *calls)
+	})
+
+	t.Run("should not retry non retryable status codes", func(t *testing.T) {
+		client, calls := newFlakyClient(mockStatusResponse(400, "", nil))
+
+		_, attempts, err := NewRetryPolicy(mockRetryConfig).Do(context.Background(), client, newRequest())
+
+		assert.EqualError(t, err, "unexpected status code 400")
+		assert.Equal(t, 1, attempts)
+		assert.Equal(t, int32(1), *calls)
+	})
+
+	t.Run("should give up when retry after goes past the deadline", func(t *testing.T) {
+		header := make(http.Header)
+		header.Set("Retry-After", "30")
+		client, calls := newFlakyClient(mockStatusResponse(429, "", header))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		start := time.Now()
+		_, attempts, err := NewRetryPolicy(mockRetryConfig).Do(ctx, client, newRequest())
+
+		assert.EqualError(t, err, "unexpected status code 429")
+		assert.Equal(t, 1, attempts)
+		assert.Equal(t, int32(1), *calls)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("should keep the backoff within the jitter and max backoff", func(t *testing.T) {
+		policy := NewRetryPolicy(config.RetryConfig{
+			InitialBackoff: 100 * time.Millisecond,
+			MaxBackoff:     300 * time.Millisecond,
+			Multiplier:     2,
+			Jitter:         &mockJitter,
+		})
+
+		for i := 0; i < 100; i++ {
+			assert.InDelta(t, float64(100*time.Millisecond), float64(policy.backoff(1)), float64(20*time.Millisecond))
+			assert.InDelta(t, float64(200*time.Millisecond), float64(policy.backoff(2)), float64(40*time.Millisecond))
+			assert.InDelta(t, float64(300*time.Millisecond), float64(policy.backoff(5)), float64(60*time.Millisecond))
+		}
+	})
+
+	t.Run("should retry flaky suppliers when listing hotels", func(t *testing.T) {
+		client, _ := newFlakyClient(
+			mockStatusResponse(503, "", nil),
+			mockStatusResponse(200, `[{"Id": "mock-hotel-id"}]`, nil),
+		)
+		suppliers := mockSupplierConfigs()[2:]
+		suppliers[0].Retry = mockRetryConfig
+
+		r, err := NewHotelRepo(client, suppliers)
+		assert.NoError(t, err)
+
+		results := r.ListHotels(context.Background())
+
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, 200, results[0].StatusCode)
+		assert.Equal(t, 2, results[0].Attempts)
+		assert.Equal(t, 1, results[0].RecordCount)
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "empty", value: "", want: 0},
+		{name: "seconds", value: "3", want: 3 * time.Second},
+		{name: "negative seconds", value: "-3", want: 0},
+		{name: "http date", value: now.Add(5 * time.Second).Format(http.TimeFormat), want: 5 * time.Second},
+		{name: "http date in the past", value: now.Add(-5 * time.Second).Format(http.TimeFormat), want: 0},
+		{name: "invalid", value: "mock-value", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseRetryAfter(tt.value, now))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	// Closed lets every call through
+	Closed State = "closed"
+	// Open rejects every call until the cool down has passed
+	Open State = "open"
+	// HalfOpen lets a single trial call through to decide whether to close or open again
+	HalfOpen State = "half-open"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Status is a point in time view of the breaker
+type Status struct {
+	State               State
+	ConsecutiveFailures int
+	OpenedAt            time.Time // zero if the breaker is closed
+}
+
+// Breaker is a circuit breaker that opens after a number of consecutive failures
+type Breaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	coolDown         time.Duration
+	state            State
+	failures         int
+	openedAt         time.Time
+	trialInFlight    bool
+	now              func() time.Time
+}
+
+func New(failureThreshold int, coolDown time.Duration) *Breaker {
+	return &Breaker{
+		failureThreshold: failureThreshold,
+		coolDown:         coolDown,
+		state:            Closed,
+		now:              time.Now,
+	}
+}
+
+// Allow reports whether a call can be made. Every allowed call must be followed by Success or Failure
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.coolDown {
+			return false
+		}
+		b.state = HalfOpen
+		b.trialInFlight = true
+		return true
+	case HalfOpen:
+		if b.trialInFlight {
+			return false
+		}
+		b.trialInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success closes the breaker and resets the failures
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = Closed
+	b.failures = 0
+	b.openedAt = time.Time{}
+	b.trialInFlight = false
+}
+
+// Failure opens the breaker if the failed call was a trial or the failure threshold is reached
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trialInFlight = false
+
+	if b.state == HalfOpen || b.failures >= b.failureThreshold {
+		b.state = Open
+		b.openedAt = b.now()
+	}
+}
+
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return Status{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		OpenedAt:            b.openedAt,
+	}
+}
@@ -0,0 +1,77 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	setupBreaker := func() (*Breaker, *time.Time) {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		b := New(2, time.Minute)
+		b.now = func() time.Time { return now }
+
+		return b, &now
+	}
+
+	t.Run("should open after consecutive failures reach the threshold", func(t *testing.T) {
+		b, now := setupBreaker()
+
+		assert.True(t, b.Allow())
+		b.Failure()
+		assert.Equal(t, Closed, b.Status().State)
+
+		assert.True(t, b.Allow())
+		b.Failure()
+
+		assert.Equal(t, Status{State: Open, ConsecutiveFailures: 2, OpenedAt: *now}, b.Status())
+		assert.False(t, b.Allow())
+	})
+
+	t.Run("should reset failures on success", func(t *testing.T) {
+		b, _ := setupBreaker()
+
+		b.Allow()
+		b.Failure()
+		b.Allow()
+		b.Success()
+		b.Allow()
+		b.Failure()
+
+		assert.Equal(t, Status{State: Closed, ConsecutiveFailures: 1}, b.Status())
+	})
+
+	t.Run("should let a single trial through after the cool down", func(t *testing.T) {
+		b, now := setupBreaker()
+		b.Allow()
+		b.Failure()
+		b.Allow()
+		b.Failure()
+
+		*now = now.Add(time.Minute)
+
+		assert.True(t, b.Allow())
+		assert.Equal(t, HalfOpen, b.Status().State)
+		assert.False(t, b.Allow())
+
+		b.Success()
+		assert.Equal(t, Status{State: Closed}, b.Status())
+	})
+
+	t.Run("should open again when the trial fails", func(t *testing.T) {
+		b, now := setupBreaker()
+		b.Allow()
+		b.Failure()
+		b.Allow()
+		b.Failure()
+
+		*now = now.Add(time.Minute)
+		b.Allow()
+		b.Failure()
+
+		assert.Equal(t, Status{State: Open, ConsecutiveFailures: 3, OpenedAt: *now}, b.Status())
+		assert.False(t, b.Allow())
+	})
+}
@@ -6,6 +6,9 @@ import (
 	"github.com/patrickmn/go-cache"
 )
 
+// NoExpiration is used to keep a value in the cache until it is replaced
+const NoExpiration = cache.NoExpiration
+
 type CacheInterface interface {
 	Set(key string, value interface{}, d time.Duration)
 	Get(key string) (interface{}, bool)
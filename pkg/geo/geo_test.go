@@ -0,0 +1,46 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePoint(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Point
+		err      bool
+	}{
+		{value: "1.264751,103.824006", expected: Point{Lat: 1.264751, Lng: 103.824006}},
+		{value: " -33.8688 , 151.2093 ", expected: Point{Lat: -33.8688, Lng: 151.2093}},
+		{value: "1.26", err: true},
+		{value: "1.26,103.8,5", err: true},
+		{value: "abc,103.8", err: true},
+		{value: "1.26,abc", err: true},
+		{value: "91,103.8", err: true},
+		{value: "1.26,-181", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			point, err := ParsePoint(tt.value)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, point)
+		})
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	singapore := Point{Lat: 1.3521, Lng: 103.8198}
+	tokyo := Point{Lat: 35.6762, Lng: 139.6503}
+
+	assert.Equal(t, 0.0, DistanceKm(singapore, singapore))
+	assert.InDelta(t, 5320, DistanceKm(singapore, tokyo), 10)
+	assert.Equal(t, DistanceKm(singapore, tokyo), DistanceKm(tokyo, singapore))
+}
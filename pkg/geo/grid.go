@@ -0,0 +1,180 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BoundingBox is an area between two latitudes and two longitudes, in degrees
+type BoundingBox struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// ParseBoundingBox parses a bounding box formatted as "minLat,minLng,maxLat,maxLng"
+func ParseBoundingBox(value string) (BoundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, fmt.Errorf("%q is not formatted as minLat,minLng,maxLat,maxLng", value)
+	}
+
+	values := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		val, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("%q has an invalid coordinate %q", value, part)
+		}
+		values = append(values, val)
+	}
+
+	box := BoundingBox{MinLat: values[0], MinLng: values[1], MaxLat: values[2], MaxLng: values[3]}
+	return box, box.Validate()
+}
+
+// Validate checks that the corners are valid points and the minimums are not above the maximums.
+// Boxes crossing the antimeridian are not supported
+func (b BoundingBox) Validate() error {
+	for _, corner := range []Point{{Lat: b.MinLat, Lng: b.MinLng}, {Lat: b.MaxLat, Lng: b.MaxLng}} {
+		if err := corner.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if b.MinLat > b.MaxLat || b.MinLng > b.MaxLng {
+		return fmt.Errorf("min latitude and longitude must not be above the max latitude and longitude")
+	}
+
+	return nil
+}
+
+func (b BoundingBox) Contains(p Point) bool {
+	return p.Lat >= b.MinLat && p.Lat <= b.MaxLat && p.Lng >= b.MinLng && p.Lng <= b.MaxLng
+}
+
+// boxesAround returns the boxes covering every point within the radius of the center,
+// split in two if the area crosses the antimeridian
+func boxesAround(center Point, radiusKm float64) []BoundingBox {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	minLat, maxLat := math.Max(-90, center.Lat-dLat), math.Min(90, center.Lat+dLat)
+
+	// near the poles every longitude can be within the radius
+	cosLat := math.Min(math.Cos(radians(minLat)), math.Cos(radians(maxLat)))
+	if cosLat <= 0 || dLat/cosLat >= 180 {
+		return []BoundingBox{{MinLat: minLat, MinLng: -180, MaxLat: maxLat, MaxLng: 180}}
+	}
+
+	dLng := dLat / cosLat
+	minLng, maxLng := center.Lng-dLng, center.Lng+dLng
+	switch {
+	case minLng < -180:
+		return []BoundingBox{
+			{MinLat: minLat, MinLng: -180, MaxLat: maxLat, MaxLng: maxLng},
+			{MinLat: minLat, MinLng: minLng + 360, MaxLat: maxLat, MaxLng: 180},
+		}
+	case maxLng > 180:
+		return []BoundingBox{
+			{MinLat: minLat, MinLng: minLng, MaxLat: maxLat, MaxLng: 180},
+			{MinLat: minLat, MinLng: -180, MaxLat: maxLat, MaxLng: maxLng - 360},
+		}
+	default:
+		return []BoundingBox{{MinLat: minLat, MinLng: minLng, MaxLat: maxLat, MaxLng: maxLng}}
+	}
+}
+
+type cell struct {
+	lat int
+	lng int
+}
+
+type gridEntry struct {
+	id    int
+	point Point
+}
+
+// Grid is a spatial index that buckets points into cells of a fixed size in degrees,
+// so a query only checks the points in the cells overlapping its area. It is not safe to add points while querying
+type Grid struct {
+	cellSize float64
+	cells    map[cell][]gridEntry
+}
+
+func NewGrid(cellSize float64) *Grid {
+	return &Grid{
+		cellSize: cellSize,
+		cells:    map[cell][]gridEntry{},
+	}
+}
+
+func (g *Grid) cellOf(p Point) cell {
+	return cell{
+		lat: int(math.Floor(p.Lat / g.cellSize)),
+		lng: int(math.Floor(p.Lng / g.cellSize)),
+	}
+}
+
+func (g *Grid) Add(id int, p Point) {
+	c := g.cellOf(p)
+	g.cells[c] = append(g.cells[c], gridEntry{id: id, point: p})
+}
+
+// WithinBox returns the sorted ids of the points in the box
+func (g *Grid) WithinBox(box BoundingBox) []int {
+	ids := []int{}
+	g.search(box, func(entry gridEntry) {
+		ids = append(ids, entry.id)
+	})
+	sort.Ints(ids)
+
+	return ids
+}
+
+// WithinRadius returns the sorted ids of the points within the radius of the center
+func (g *Grid) WithinRadius(center Point, radiusKm float64) []int {
+	seen := map[int]bool{}
+	ids := []int{}
+	for _, box := range boxesAround(center, radiusKm) {
+		g.search(box, func(entry gridEntry) {
+			if !seen[entry.id] && DistanceKm(center, entry.point) <= radiusKm {
+				seen[entry.id] = true
+				ids = append(ids, entry.id)
+			}
+		})
+	}
+	sort.Ints(ids)
+
+	return ids
+}
+
+// search calls fn with every point in the box. If the box covers more cells than the grid has,
+// the cells of the grid are checked instead of the cells of the box
+func (g *Grid) search(box BoundingBox, fn func(gridEntry)) {
+	min, max := g.cellOf(Point{Lat: box.MinLat, Lng: box.MinLng}), g.cellOf(Point{Lat: box.MaxLat, Lng: box.MaxLng})
+
+	visit := func(entries []gridEntry) {
+		for _, entry := range entries {
+			if box.Contains(entry.point) {
+				fn(entry)
+			}
+		}
+	}
+
+	if (max.lat-min.lat+1)*(max.lng-min.lng+1) > len(g.cells) {
+		for c, entries := range g.cells {
+			if c.lat >= min.lat && c.lat <= max.lat && c.lng >= min.lng && c.lng <= max.lng {
+				visit(entries)
+			}
+		}
+		return
+	}
+
+	for lat := min.lat; lat <= max.lat; lat++ {
+		for lng := min.lng; lng <= max.lng; lng++ {
+			visit(g.cells[cell{lat: lat, lng: lng}])
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package geo
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBoundingBox(t *testing.T) {
+	box, err := ParseBoundingBox("1.2, 103.6,1.5,104.1")
+	assert.NoError(t, err)
+	assert.Equal(t, BoundingBox{MinLat: 1.2, MinLng: 103.6, MaxLat: 1.5, MaxLng: 104.1}, box)
+
+	for _, value := range []string{"1.2,103.6,1.5", "1.2,abc,1.5,104.1", "1.5,103.6,1.2,104.1", "1.2,104.1,1.5,103.6", "-91,103.6,1.5,104.1"} {
+		_, err := ParseBoundingBox(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestGrid(t *testing.T) {
+	points := []Point{
+		{Lat: 1.3521, Lng: 103.8198},  // 0 singapore
+		{Lat: 1.2840, Lng: 103.8607},  // 1 marina bay, ~9km from singapore
+		{Lat: 13.7563, Lng: 100.5018}, // 2 bangkok
+		{Lat: -17.7134, Lng: 178.065}, // 3 fiji, west of the antimeridian
+		{Lat: -16.5782, Lng: -179.41}, // 4 taveuni, east of the antimeridian, ~300km from fiji
+		{Lat: 89.9, Lng: 10},          // 5 north pole
+		{Lat: 89.9, Lng: -170},        // 6 north pole, ~22km away across the pole
+	}
+
+	grid := NewGrid(0.5)
+	for id, point := range points {
+		grid.Add(id, point)
+	}
+
+	t.Run("should return the points in the box", func(t *testing.T) {
+		assert.Equal(t, []int{0, 1}, grid.WithinBox(BoundingBox{MinLat: 1.2, MinLng: 103.6, MaxLat: 1.5, MaxLng: 104.1}))
+		assert.Equal(t, []int{0, 1, 2}, grid.WithinBox(BoundingBox{MinLat: 0, MinLng: 100, MaxLat: 20, MaxLng: 110}))
+		assert.Equal(t, []int{}, grid.WithinBox(BoundingBox{MinLat: 50, MinLng: 0, MaxLat: 60, MaxLng: 10}))
+		assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, grid.WithinBox(BoundingBox{MinLat: -90, MinLng: -180, MaxLat: 90, MaxLng: 180}))
+	})
+
+	t.Run("should return the points within the radius", func(t *testing.T) {
+		assert.Equal(t, []int{0}, grid.WithinRadius(points[0], 5))
+		assert.Equal(t, []int{0, 1}, grid.WithinRadius(points[0], 10))
+		assert.Equal(t, []int{0, 1, 2}, grid.WithinRadius(points[0], 2000))
+	})
+
+	t.Run("should return the points within the radius across the antimeridian", func(t *testing.T) {
+		assert.Equal(t, []int{3, 4}, grid.WithinRadius(points[3], 300))
+		assert.Equal(t, []int{3, 4}, grid.WithinRadius(points[4], 300))
+	})
+
+	t.Run("should return the points within the radius across the pole", func(t *testing.T) {
+		assert.Equal(t, []int{5, 6}, grid.WithinRadius(points[5], 30))
+	})
+
+	t.Run("should return the same points as a full scan", func(t *testing.T) {
+		random := rand.New(rand.NewSource(1))
+		randomPoints := []Point{}
+		randomGrid := NewGrid(1)
+		for id := 0; id < 2000; id++ {
+			point := Point{Lat: random.Float64()*180 - 90, Lng: random.Float64()*360 - 180}
+			randomPoints = append(randomPoints, point)
+			randomGrid.Add(id, point)
+		}
+
+		for i := 0; i < 50; i++ {
+			center := Point{Lat: random.Float64()*180 - 90, Lng: random.Float64()*360 - 180}
+			radius := random.Float64() * 3000
+
+			expected := []int{}
+			for id, point := range randomPoints {
+				if DistanceKm(center, point) <= radius {
+					expected = append(expected, id)
+				}
+			}
+			sort.Ints(expected)
+
+			assert.Equal(t, expected, randomGrid.WithinRadius(center, radius))
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	after := time.Date(2024, 1, 31, 10, 7, 30, 0, time.UTC) // a wednesday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "@every 15m", want: after.Add(15 * time.Minute)},
+		{spec: "@hourly", want: time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{spec: "@daily", want: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "* * * * *", want: time.Date(2024, 1, 31, 10, 8, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", want: time.Date(2024, 1, 31, 10, 15, 0, 0, time.UTC)},
+		{spec: "0-5,30 9-17 * * *", want: time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{spec: "0 3 * * 1-5", want: time.Date(2024, 2, 1, 3, 0, 0, 0, time.UTC)},
+		{spec: "0 3 * * 0", want: time.Date(2024, 2, 4, 3, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 15 * 5", want: time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(after))
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"@every",
+		"@every -1m",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"mock-value * * * *",
+	}
+
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			schedule, err := Parse(spec)
+
+			assert.Nil(t, schedule)
+			assert.ErrorContains(t, err, "invalid schedule")
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+type job struct {
+	name     string
+	schedule Schedule
+	run      func(ctx context.Context)
+}
+
+// Scheduler runs jobs on their schedules until its context is done. Each job runs in its own go routine,
+// so a slow job does not delay the others, and a job never overlaps with itself
+type Scheduler struct {
+	jobs []job
+	wg   sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+func (s *Scheduler) Add(name string, schedule Schedule, run func(ctx context.Context)) {
+	s.jobs = append(s.jobs, job{
+		name:     name,
+		schedule: schedule,
+		run:      run,
+	})
+}
+
+// Start runs the jobs in the background until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go func(j job) {
+			defer s.wg.Done()
+			s.runJob(ctx, j)
+		}(j)
+	}
+}
+
+// Wait blocks until every job has stopped after the context passed to Start is done
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) runJob(ctx context.Context, j job) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("job %s has no next run, stopping it", j.name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			j.run(ctx)
+		}
+	}
+}
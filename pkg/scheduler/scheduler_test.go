@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler(t *testing.T) {
+	t.Run("should run jobs on their schedule until the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		runs := int32(0)
+
+		s := New()
+		s.Add("mock-job", Every(10*time.Millisecond), func(ctx context.Context) {
+			atomic.AddInt32(&runs, 1)
+		})
+		s.Start(ctx)
+
+		time.Sleep(55 * time.Millisecond)
+		cancel()
+		s.Wait()
+		stopped := atomic.LoadInt32(&runs)
+		time.Sleep(20 * time.Millisecond)
+
+		assert.GreaterOrEqual(t, stopped, int32(2))
+		assert.Equal(t, stopped, atomic.LoadInt32(&runs))
+	})
+}
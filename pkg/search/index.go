@@ -0,0 +1,116 @@
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+// bm25 parameters, k1 limits how much repeating a term adds to the score and b how much long documents are penalized
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Field is a text of a document. Terms in fields with a higher weight count as if they appear more often
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+type posting struct {
+	doc int
+	tf  float64
+}
+
+// Match is a document matching a query with its relevance score
+type Match struct {
+	ID    int
+	Score float64
+}
+
+// Index is an inverted index from terms to the documents they appear in, scoring matches with BM25.
+// It is not safe to add documents while searching
+type Index struct {
+	postings  map[string][]posting
+	docLength map[int]float64
+	total     float64
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings:  map[string][]posting{},
+		docLength: map[int]float64{},
+	}
+}
+
+// Add indexes the fields of a document. Every document must be added once
+func (idx *Index) Add(id int, fields ...Field) {
+	frequencies := map[string]float64{}
+	length := 0.0
+
+	for _, field := range fields {
+		for _, term := range Tokenize(field.Text) {
+			frequencies[term] += field.Weight
+			length += field.Weight
+		}
+	}
+
+	for term, tf := range frequencies {
+		idx.postings[term] = append(idx.postings[term], posting{doc: id, tf: tf})
+	}
+	idx.docLength[id] = length
+	idx.total += length
+}
+
+// Search returns the documents containing every term of the query, sorted by id. An empty query matches nothing
+func (idx *Index) Search(query string) []Match {
+	terms := uniqueTerms(Tokenize(query))
+	if len(terms) == 0 || len(idx.docLength) == 0 {
+		return []Match{}
+	}
+
+	docs := float64(len(idx.docLength))
+	averageLength := idx.total / docs
+	scores := map[int]float64{}
+	counts := map[int]int{}
+
+	for _, term := range terms {
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			return []Match{}
+		}
+
+		df := float64(len(postings))
+		idf := math.Log(1 + (docs-df+0.5)/(df+0.5))
+		for _, p := range postings {
+			norm := 1 - b + b*idx.docLength[p.doc]/averageLength
+			scores[p.doc] += idf * p.tf * (k1 + 1) / (p.tf + k1*norm)
+			counts[p.doc]++
+		}
+	}
+
+	matches := []Match{}
+	for doc, score := range scores {
+		if counts[doc] == len(terms) {
+			matches = append(matches, Match{ID: doc, Score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ID < matches[j].ID
+	})
+
+	return matches
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,93 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "Marina Bay Sands", expected: []string{"marina", "bay", "sands"}},
+		{text: "  Café  Crème, Zürich!", expected: []string{"cafe", "creme", "zurich"}},
+		{text: "8 Sentosa Gateway, 098269", expected: []string{"8", "sentosa", "gateway", "098269"}},
+		{text: "Straße ÆON", expected: []string{"strasse", "aeon"}},
+		{text: "dry-cleaning", expected: []string{"dry", "cleaning"}},
+		{text: " ,.", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			tokens := Tokenize(tt.text)
+			if len(tt.expected) == 0 {
+				assert.Empty(t, tokens)
+				return
+			}
+			assert.Equal(t, tt.expected, tokens)
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected float64
+	}{
+		{a: "Marina Bay Sands", b: "marina bay sands", expected: 1},
+		{a: "Café Crème", b: "cafe creme!", expected: 1},
+		{a: "Marina Bay Sands", b: "Marina Bay Sand", expected: 1 - 1.0/16},
+		{a: "abc", b: "xyz", expected: 0},
+		{a: "", b: "", expected: 1},
+		{a: "Hotel", b: "", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, Similarity(tt.a, tt.b), 1e-9)
+		})
+	}
+}
+
+func TestIndex(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(0, Field{Text: "Marina Bay Sands", Weight: 3}, Field{Text: "Infinity pool overlooking the bay", Weight: 1})
+	idx.Add(1, Field{Text: "Beach Villas", Weight: 3}, Field{Text: "Villas on the beach with a private pool", Weight: 1})
+	idx.Add(2, Field{Text: "Bay Hotel", Weight: 3}, Field{Text: "A small hotel near Marina Bay, with no pool", Weight: 1})
+	idx.Add(3, Field{Text: "Café Hôtel", Weight: 3})
+
+	t.Run("should only match documents with every term", func(t *testing.T) {
+		matches := idx.Search("marina bay pool")
+		assert.Len(t, matches, 2)
+		assert.Equal(t, 0, matches[0].ID)
+		assert.Equal(t, 2, matches[1].ID)
+	})
+
+	t.Run("should score terms in weighted fields higher", func(t *testing.T) {
+		matches := idx.Search("marina")
+		assert.Len(t, matches, 2)
+		assert.Greater(t, matches[0].Score, matches[1].Score)
+	})
+
+	t.Run("should score rare terms higher", func(t *testing.T) {
+		pool := idx.Search("pool")
+		beach := idx.Search("beach")
+		assert.Len(t, pool, 3)
+		assert.Len(t, beach, 1)
+		assert.Greater(t, beach[0].Score, pool[1].Score)
+	})
+
+	t.Run("should fold the query", func(t *testing.T) {
+		assert.Equal(t, idx.Search("café"), idx.Search("CAFE"))
+		assert.Len(t, idx.Search("CAFE"), 1)
+		assert.Len(t, idx.Search("Hôtel"), 2)
+	})
+
+	t.Run("should match nothing", func(t *testing.T) {
+		assert.Empty(t, idx.Search(""))
+		assert.Empty(t, idx.Search("marina unknown"))
+		assert.Empty(t, NewIndex().Search("marina"))
+	})
+}
@@ -0,0 +1,236 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MinTypoPrefixLength is the shortest prefix that is corrected for typos, shorter prefixes have too many corrections
+const MinTypoPrefixLength = 3
+
+// Suggestion is a text that can complete a prefix
+type Suggestion struct {
+	Text   string
+	Kind   string
+	Ref    string // optional reference to what the text belongs to
+	Weight int    // number of times the text was added
+}
+
+// suggestKey is a folded word sequence pointing at a suggestion. Every suggestion has a key starting at each of its words
+type suggestKey struct {
+	key       string
+	entry     int
+	fullStart bool // true if the key starts at the first word of the text
+}
+
+// Suggester completes prefixes with the texts added to it, allowing a single typo in the prefix.
+// The keys are kept sorted so every prefix is found with a binary search. Build must be called after adding texts
+type Suggester struct {
+	entries  []Suggestion
+	keys     []suggestKey
+	byText   map[string]int
+	alphabet []rune
+}
+
+func NewSuggester() *Suggester {
+	return &Suggester{
+		byText: map[string]int{},
+	}
+}
+
+// Add adds a text of a kind. Adding the same text, kind and ref again increases its weight instead
+func (s *Suggester) Add(text string, kind string, ref string) {
+	words := Tokenize(text)
+	if len(words) == 0 {
+		return
+	}
+
+	id := kind + "\x00" + ref + "\x00" + strings.Join(words, " ")
+	if entry, exists := s.byText[id]; exists {
+		s.entries[entry].Weight++
+		return
+	}
+
+	entry := len(s.entries)
+	s.byText[id] = entry
+	s.entries = append(s.entries, Suggestion{Text: strings.TrimSpace(text), Kind: kind, Ref: ref, Weight: 1})
+
+	for i := range words {
+		s.keys = append(s.keys, suggestKey{key: strings.Join(words[i:], " "), entry: entry, fullStart: i == 0})
+	}
+}
+
+// Build sorts the keys, and must be called before Suggest
+func (s *Suggester) Build() {
+	sort.Slice(s.keys, func(i, j int) bool {
+		return s.keys[i].key < s.keys[j].key
+	})
+
+	runes := map[rune]bool{}
+	for _, key := range s.keys {
+		for _, r := range key.key {
+			runes[r] = true
+		}
+	}
+
+	s.alphabet = make([]rune, 0, len(runes))
+	for r := range runes {
+		s.alphabet = append(s.alphabet, r)
+	}
+	sort.Slice(s.alphabet, func(i, j int) bool {
+		return s.alphabet[i] < s.alphabet[j]
+	})
+}
+
+// ranks of the ways a suggestion can match, lower is better
+const (
+	rankFullPrefix = iota
+	rankWordPrefix
+	rankTypo
+)
+
+// Suggest returns up to limit suggestions starting with the prefix, or with the prefix one typo away.
+// Matches at the start of the text come first, then matches at a later word, then matches with a typo,
+// each ordered by weight
+func (s *Suggester) Suggest(prefix string, limit int) []Suggestion {
+	prefix = strings.Join(Tokenize(prefix), " ")
+	if prefix == "" || limit <= 0 {
+		return []Suggestion{}
+	}
+
+	top := &topSuggestions{suggester: s, limit: limit}
+	match := func(p string, typo bool) {
+		start := sort.Search(len(s.keys), func(i int) bool {
+			return s.keys[i].key >= p
+		})
+
+		for i := start; i < len(s.keys) && strings.HasPrefix(s.keys[i].key, p); i++ {
+			rank := rankTypo
+			if !typo && s.keys[i].fullStart {
+				rank = rankFullPrefix
+			} else if !typo {
+				rank = rankWordPrefix
+			}
+
+			top.add(candidate{entry: s.keys[i].entry, rank: rank})
+		}
+	}
+
+	match(prefix, false)
+
+	// matches with a typo are always ranked after the other matches, so they are only needed if there are not enough
+	if len(top.candidates) < limit && utf8.RuneCountInString(prefix) >= MinTypoPrefixLength {
+		for _, variant := range s.edits(prefix) {
+			match(variant, true)
+		}
+	}
+
+	suggestions := make([]Suggestion, 0, len(top.candidates))
+	for _, c := range top.candidates {
+		suggestions = append(suggestions, s.entries[c.entry])
+	}
+
+	return suggestions
+}
+
+type candidate struct {
+	entry int
+	rank  int
+}
+
+// topSuggestions keeps the best candidates up to the limit in order, with each entry at the best rank it matched.
+// A short prefix can match thousands of keys, so the matches are never all collected and sorted
+type topSuggestions struct {
+	suggester  *Suggester
+	limit      int
+	candidates []candidate
+}
+
+func (t *topSuggestions) better(x, y candidate) bool {
+	a, b := t.suggester.entries[x.entry], t.suggester.entries[y.entry]
+	switch {
+	case x.rank != y.rank:
+		return x.rank < y.rank
+	case a.Weight != b.Weight:
+		return a.Weight > b.Weight
+	case len(a.Text) != len(b.Text):
+		return len(a.Text) < len(b.Text)
+	case a.Text != b.Text:
+		return a.Text < b.Text
+	default:
+		return a.Ref < b.Ref
+	}
+}
+
+func (t *topSuggestions) add(c candidate) {
+	if len(t.candidates) == t.limit && !t.better(c, t.candidates[t.limit-1]) {
+		return
+	}
+
+	for i, existing := range t.candidates {
+		if existing.entry != c.entry {
+			continue
+		}
+		if existing.rank <= c.rank {
+			return
+		}
+		t.candidates = append(t.candidates[:i], t.candidates[i+1:]...)
+		break
+	}
+
+	i := sort.Search(len(t.candidates), func(i int) bool {
+		return t.better(c, t.candidates[i])
+	})
+	t.candidates = append(t.candidates, candidate{})
+	copy(t.candidates[i+1:], t.candidates[i:])
+	t.candidates[i] = c
+
+	if len(t.candidates) > t.limit {
+		t.candidates = t.candidates[:t.limit]
+	}
+}
+
+// edits returns every prefix one deletion, substitution, insertion or transposition away from the prefix,
+// using only the letters that appear in the keys
+func (s *Suggester) edits(prefix string) []string {
+	runes := []rune(prefix)
+	seen := map[string]bool{prefix: true}
+	variants := []string{}
+
+	add := func(variant []rune) {
+		if v := string(variant); !seen[v] {
+			seen[v] = true
+			variants = append(variants, v)
+		}
+	}
+
+	for i := 0; i <= len(runes); i++ {
+		for _, r := range s.alphabet {
+			add(concatRunes(runes[:i], []rune{r}, runes[i:]))
+		}
+
+		if i == len(runes) {
+			break
+		}
+
+		add(concatRunes(runes[:i], runes[i+1:]))
+		for _, r := range s.alphabet {
+			add(concatRunes(runes[:i], []rune{r}, runes[i+1:]))
+		}
+		if i+1 < len(runes) {
+			add(concatRunes(runes[:i], []rune{runes[i+1], runes[i]}, runes[i+2:]))
+		}
+	}
+
+	return variants
+}
+
+func concatRunes(parts ...[]rune) []rune {
+	result := []rune{}
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+
+	return result
+}
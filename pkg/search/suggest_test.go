@@ -0,0 +1,86 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func suggestionTexts(suggestions []Suggestion) []string {
+	texts := []string{}
+	for _, suggestion := range suggestions {
+		texts = append(texts, suggestion.Text)
+	}
+
+	return texts
+}
+
+func TestSuggester(t *testing.T) {
+	s := NewSuggester()
+	s.Add("Marina Bay Sands", "hotel", "mbs")
+	s.Add("Bay Hotel", "hotel", "bay")
+	s.Add("InterContinental Singapore Robertson Quay", "hotel", "ic")
+	s.Add("Singapore", "city", "")
+	s.Add("Singapore", "city", "")
+	s.Add("Sintra", "city", "")
+	s.Add("Zürich", "city", "")
+	s.Build()
+
+	tests := []struct {
+		name     string
+		prefix   string
+		expected []string
+	}{
+		{name: "start of the text", prefix: "mari", expected: []string{"Marina Bay Sands"}},
+		{name: "start of the text before a later word", prefix: "bay", expected: []string{"Bay Hotel", "Marina Bay Sands"}},
+		{name: "heavier texts first, and typos last", prefix: "sin", expected: []string{"Singapore", "Sintra", "InterContinental Singapore Robertson Quay", "Marina Bay Sands"}},
+		{name: "several words", prefix: "marina b", expected: []string{"Marina Bay Sands"}},
+		{name: "case and accents", prefix: "ZUR", expected: []string{"Zürich"}},
+		{name: "substitution", prefix: "maeina", expected: []string{"Marina Bay Sands"}},
+		{name: "deletion", prefix: "mrina", expected: []string{"Marina Bay Sands"}},
+		{name: "insertion", prefix: "marrina", expected: []string{"Marina Bay Sands"}},
+		{name: "transposition", prefix: "amrina", expected: []string{"Marina Bay Sands"}},
+		{name: "exact matches before typos", prefix: "sint", expected: []string{"Sintra", "Singapore", "InterContinental Singapore Robertson Quay"}},
+		{name: "more than one typo", prefix: "mxxina", expected: []string{}},
+		{name: "no typo correction for short prefixes", prefix: "xa", expected: []string{}},
+		{name: "empty", prefix: " ", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, suggestionTexts(s.Suggest(tt.prefix, 10)))
+		})
+	}
+
+	t.Run("should return at most the limit", func(t *testing.T) {
+		assert.Equal(t, []string{"Singapore"}, suggestionTexts(s.Suggest("sin", 1)))
+	})
+
+	t.Run("should keep the kind, ref and weight", func(t *testing.T) {
+		assert.Equal(t, []Suggestion{
+			{Text: "Singapore", Kind: "city", Weight: 2},
+			{Text: "Sintra", Kind: "city", Weight: 1},
+		}, s.Suggest("sin", 2))
+		assert.Equal(t, []Suggestion{{Text: "Bay Hotel", Kind: "hotel", Ref: "bay", Weight: 1}}, s.Suggest("bay ho", 10))
+	})
+}
+
+func BenchmarkSuggest(b *testing.B) {
+	words := []string{"grand", "marina", "royal", "park", "bay", "garden", "palace", "river", "plaza", "harbour"}
+	s := NewSuggester()
+	for i := 0; i < 50000; i++ {
+		s.Add(fmt.Sprintf("%s %s hotel %d", words[i%len(words)], words[(i/len(words))%len(words)], i), "hotel", fmt.Sprint(i))
+		s.Add(fmt.Sprintf("city %d", i%500), "city", "")
+	}
+	s.Build()
+
+	// every prefix matches thousands of hotels, the typo has to be corrected before it matches any
+	for _, prefix := range []string{"harbour", "harbuor", "city 4"} {
+		b.Run(prefix, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.Suggest(prefix, 10)
+			}
+		})
+	}
+}
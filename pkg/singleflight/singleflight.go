@@ -0,0 +1,59 @@
+package singleflight
+
+import "sync"
+
+type call struct {
+	wg      sync.WaitGroup
+	val     interface{}
+	err     error
+	waiting int // callers waiting for the result besides the one running the call
+}
+
+// Group coalesces concurrent calls with the same key, so only one of them runs and the others share its result
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do runs fn if there is no call with the same key in flight, otherwise it waits for that call and returns its result.
+// shared is true if the result was returned to more than one caller
+func (g *Group) Do(key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*call{}
+	}
+
+	if c, exists := g.calls[key]; exists {
+		c.waiting++
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
+
+// Waiting returns how many callers are waiting for the call in flight with the key, 0 if there is none
+func (g *Group) Waiting(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c, exists := g.calls[key]; exists {
+		return c.waiting
+	}
+
+	return 0
+}
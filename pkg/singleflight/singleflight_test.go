@@ -0,0 +1,61 @@
+package singleflight
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	t.Run("should run concurrent calls with the same key once", func(t *testing.T) {
+		g := &Group{}
+		calls := int32(0)
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		results := make([]interface{}, 10)
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _, _ = g.Do("mock-key", func() (interface{}, error) {
+					atomic.AddInt32(&calls, 1)
+					<-release
+					return "mock-value", nil
+				})
+			}(i)
+		}
+
+		// the call is only released once every other caller waits for it, so none of them runs it again
+		assert.Eventually(t, func() bool { return g.Waiting("mock-key") == 9 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls)
+		assert.Equal(t, 0, g.Waiting("mock-key"))
+		for _, result := range results {
+			assert.Equal(t, "mock-value", result)
+		}
+	})
+
+	t.Run("should run again once the previous call is done", func(t *testing.T) {
+		g := &Group{}
+		calls := 0
+		fn := func() (interface{}, error) {
+			calls++
+			return nil, errors.New("mock-error")
+		}
+
+		_, err1, shared := g.Do("mock-key", fn)
+		_, err2, _ := g.Do("mock-key", fn)
+
+		assert.EqualError(t, err1, "mock-error")
+		assert.EqualError(t, err2, "mock-error")
+		assert.False(t, shared)
+		assert.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,41 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"hotel-data-merge/dto"
+	"hotel-data-merge/usecase"
+	"net/http"
+)
+
+type AdminHandler struct {
+	hotelUsecase *usecase.HotelUsecase
+}
+
+func NewAdminHandler(hotelUsecase *usecase.HotelUsecase) *AdminHandler {
+	return &AdminHandler{hotelUsecase: hotelUsecase}
+}
+
+func (h *AdminHandler) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	w.Header().Set("Content-Type", "application/json")
+
+	health := h.hotelUsecase.Health(ctx)
+	json.NewEncoder(w).Encode(&health)
+}
+
+func (h *AdminHandler) ConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	w.Header().Set("Content-Type", "application/json")
+	req := &dto.ConflictsRequest{
+		Type: r.URL.Query().Get("type"),
+	}
+
+	conflicts, err := h.hotelUsecase.Conflicts(ctx, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(&conflicts)
+}
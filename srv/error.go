@@ -0,0 +1,31 @@
+package srv
+
+import (
+	"encoding/json"
+	"errors"
+	"hotel-data-merge/dto"
+	"hotel-data-merge/usecase"
+	"net/http"
+)
+
+// writeError writes the error as a json error response, with the status code of the kind of error
+func writeError(w http.ResponseWriter, err error) {
+	status, code := http.StatusInternalServerError, "internal_error"
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCursor):
+		status, code = http.StatusBadRequest, "invalid_cursor"
+	case errors.Is(err, usecase.ErrInvalidRequest):
+		status, code = http.StatusBadRequest, "invalid_request"
+	case errors.Is(err, usecase.ErrNotFound):
+		status, code = http.StatusNotFound, "not_found"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&dto.ErrorResponse{
+		Error: dto.ErrorDetail{
+			Code:    code,
+			Message: err.Error(),
+		},
+	})
+}
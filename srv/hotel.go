@@ -3,9 +3,12 @@ package srv
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"hotel-data-merge/dto"
+	"hotel-data-merge/pkg/geo"
 	"hotel-data-merge/usecase"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -31,6 +34,132 @@ func (h *HotelHandler) ListHotelsHandler(w http.ResponseWriter, r *http.Request)
 		req.DestinationIDs = strings.Split(destinationIDsStr, ",")
 	}
 
-	hotel := h.hotelUsecase.ListHotels(ctx, req)
+	if amenitiesStr := r.URL.Query().Get("amenities"); amenitiesStr != "" {
+		req.Amenities = strings.Split(amenitiesStr, ",")
+	}
+	if countryStr := r.URL.Query().Get("country"); countryStr != "" {
+		req.Countries = strings.Split(countryStr, ",")
+	}
+	if cityStr := r.URL.Query().Get("city"); cityStr != "" {
+		req.Cities = strings.Split(cityStr, ",")
+	}
+
+	if facetsStr := r.URL.Query().Get("facets"); facetsStr != "" {
+		req.Facets = strings.Split(facetsStr, ",")
+	}
+	if includeStr := r.URL.Query().Get("include"); includeStr != "" {
+		req.Include = strings.Split(includeStr, ",")
+	}
+
+	req.AmenityMatch = r.URL.Query().Get("amenity_match")
+	req.Query = r.URL.Query().Get("q")
+	req.Match = r.URL.Query().Get("match")
+	req.Sort = r.URL.Query().Get("sort")
+	req.Order = r.URL.Query().Get("order")
+	req.Cursor = r.URL.Query().Get("cursor")
+
+	if originStr := r.URL.Query().Get("origin"); originStr != "" {
+		origin, err := geo.ParsePoint(originStr)
+		if err != nil {
+			writeError(w, fmt.Errorf("%w: origin %v", usecase.ErrInvalidRequest, err))
+			return
+		}
+		req.Origin = &origin
+	}
+
+	if nearStr := r.URL.Query().Get("near"); nearStr != "" {
+		near, err := geo.ParsePoint(nearStr)
+		if err != nil {
+			writeError(w, fmt.Errorf("%w: near %v", usecase.ErrInvalidRequest, err))
+			return
+		}
+		req.Near = &near
+	}
+
+	if radiusStr := r.URL.Query().Get("radius_km"); radiusStr != "" {
+		radius, err := strconv.ParseFloat(radiusStr, 64)
+		if err != nil || radius <= 0 {
+			writeError(w, fmt.Errorf("%w: radius_km must be a positive number", usecase.ErrInvalidRequest))
+			return
+		}
+		req.RadiusKm = radius
+	}
+
+	if bboxStr := r.URL.Query().Get("bbox"); bboxStr != "" {
+		box, err := geo.ParseBoundingBox(bboxStr)
+		if err != nil {
+			writeError(w, fmt.Errorf("%w: bbox %v", usecase.ErrInvalidRequest, err))
+			return
+		}
+		req.BoundingBox = &box
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			writeError(w, fmt.Errorf("%w: limit must be a positive number", usecase.ErrInvalidRequest))
+			return
+		}
+		req.Limit = limit
+	}
+
+	hotel, err := h.hotelUsecase.ListHotels(ctx, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(&hotel)
+}
+
+func (h *HotelHandler) SuggestHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	w.Header().Set("Content-Type", "application/json")
+	req := &dto.SuggestRequest{
+		Prefix: r.URL.Query().Get("prefix"),
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			writeError(w, fmt.Errorf("%w: limit must be a positive number", usecase.ErrInvalidRequest))
+			return
+		}
+		req.Limit = limit
+	}
+
+	suggestions, err := h.hotelUsecase.Suggest(ctx, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(&suggestions)
+}
+
+// GetHotelHandler serves /hotels/{id}
+func (h *HotelHandler) GetHotelHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	w.Header().Set("Content-Type", "application/json")
+
+	id := strings.TrimPrefix(r.URL.Path, "/hotels/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, fmt.Errorf("%w: %s", usecase.ErrNotFound, r.URL.Path))
+		return
+	}
+
+	req := &dto.GetHotelRequest{
+		HotelID: id,
+	}
+	if includeStr := r.URL.Query().Get("include"); includeStr != "" {
+		req.Include = strings.Split(includeStr, ",")
+	}
+
+	hotel, err := h.hotelUsecase.GetHotel(ctx, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
 	json.NewEncoder(w).Encode(&hotel)
 }
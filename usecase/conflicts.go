@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"hotel-data-merge/dto"
+	"hotel-data-merge/pkg/geo"
+	"hotel-data-merge/pkg/search"
+	"sort"
+	"strings"
+)
+
+// ConflictConfig are the thresholds at which the suppliers of a hotel are reported as disagreeing
+type ConflictConfig struct {
+	MaxDistanceKm     float64 // coordinates further apart conflict, so 0 reports any difference
+	MinNameSimilarity float64 // names less similar conflict, from 0 to 1, so 0 reports no names
+	Coordinates       bool    // whether distant coordinates conflict
+	Name              bool    // whether dissimilar names conflict
+	Country           bool    // whether different countries conflict
+	DestinationID     bool    // whether different destination ids conflict
+}
+
+// DefaultConflictConfig reports coordinates over a kilometre apart, names less than half similar, and different countries and destinations
+func DefaultConflictConfig() ConflictConfig {
+	return ConflictConfig{
+		MaxDistanceKm:     1,
+		MinNameSimilarity: 0.5,
+		Coordinates:       true,
+		Name:              true,
+		Country:           true,
+		DestinationID:     true,
+	}
+}
+
+// supplierHotel is the hotel of a single supplier
+type supplierHotel struct {
+	supplier string
+	hotel    Hotel
+}
+
+// detectConflicts compares the hotels of the suppliers with data and returns the conflicts of every hotel
+// the suppliers disagree on by hotel id. Only the hotels of different suppliers are compared
+func detectConflicts(results []SupplierResult, cfg ConflictConfig) map[string][]dto.Conflict {
+	hotels := map[string][]supplierHotel{}
+	for _, result := range orderSuppliers(results) {
+		if result.Err != nil && !result.Stale {
+			continue
+		}
+
+		for _, hotel := range result.Hotels {
+			hotels[hotel.HotelID] = append(hotels[hotel.HotelID], supplierHotel{supplier: result.Name, hotel: hotel})
+		}
+	}
+
+	conflicts := map[string][]dto.Conflict{}
+	for id, supplierHotels := range hotels {
+		if len(supplierHotels) < 2 {
+			continue
+		}
+
+		found := []dto.Conflict{}
+		if conflict, ok := coordinatesConflict(supplierHotels, cfg.MaxDistanceKm); cfg.Coordinates && ok {
+			found = append(found, conflict)
+		}
+		if conflict, ok := nameConflict(supplierHotels, cfg.MinNameSimilarity); cfg.Name && ok {
+			found = append(found, conflict)
+		}
+		if conflict, ok := countryConflict(supplierHotels); cfg.Country && ok {
+			found = append(found, conflict)
+		}
+		if conflict, ok := destinationIDConflict(supplierHotels); cfg.DestinationID && ok {
+			found = append(found, conflict)
+		}
+
+		if len(found) > 0 {
+			conflicts[id] = found
+		}
+	}
+
+	return conflicts
+}
+
+func coordinatesConflict(hotels []supplierHotel, maxDistanceKm float64) (dto.Conflict, bool) {
+	located := []supplierHotel{}
+	points := []geo.Point{}
+	for _, h := range hotels {
+		if point, ok := locationPoint(h.hotel.Location); ok {
+			located = append(located, h)
+			points = append(points, point)
+		}
+	}
+
+	distance := 0.0
+	for i := range located {
+		for j := i + 1; j < len(located); j++ {
+			if located[i].supplier != located[j].supplier {
+				if d := geo.DistanceKm(points[i], points[j]); d > distance {
+					distance = d
+				}
+			}
+		}
+	}
+
+	if distance <= maxDistanceKm {
+		return dto.Conflict{}, false
+	}
+
+	conflict := dto.Conflict{
+		Type:    dto.ConflictCoordinates,
+		Message: fmt.Sprintf("coordinates are up to %.1f km apart", distance),
+	}
+	for _, h := range located {
+		conflict.Values = append(conflict.Values, dto.ConflictValue{
+			Supplier: h.supplier,
+			Value:    dto.HotelLocation{Latitude: copyPointer(h.hotel.Location.Latitude), Longitude: copyPointer(h.hotel.Location.Longitude)},
+		})
+	}
+
+	return conflict, true
+}
+
+func locationPoint(location *HotelLocation) (geo.Point, bool) {
+	if location == nil || location.Latitude == nil || location.Longitude == nil {
+		return geo.Point{}, false
+	}
+
+	return geo.Point{Lat: float64(*location.Latitude), Lng: float64(*location.Longitude)}, true
+}
+
+func nameConflict(hotels []supplierHotel, minSimilarity float64) (dto.Conflict, bool) {
+	named := []supplierHotel{}
+	for _, h := range hotels {
+		if strings.TrimSpace(h.hotel.Name) != "" {
+			named = append(named, h)
+		}
+	}
+
+	similarity := 1.0
+	for i, a := range named {
+		for _, b := range named[i+1:] {
+			if a.supplier != b.supplier {
+				if s := search.Similarity(a.hotel.Name, b.hotel.Name); s < similarity {
+					similarity = s
+				}
+			}
+		}
+	}
+
+	if similarity >= minSimilarity {
+		return dto.Conflict{}, false
+	}
+
+	conflict := dto.Conflict{
+		Type:    dto.ConflictName,
+		Message: fmt.Sprintf("names are only %.0f%% similar", similarity*100),
+	}
+	for _, h := range named {
+		conflict.Values = append(conflict.Values, dto.ConflictValue{Supplier: h.supplier, Value: h.hotel.Name})
+	}
+
+	return conflict, true
+}
+
+func countryConflict(hotels []supplierHotel) (dto.Conflict, bool) {
+	conflict := dto.Conflict{
+		Type:    dto.ConflictCountry,
+		Message: "countries differ",
+	}
+	countries := map[string]bool{}
+	for _, h := range hotels {
+		if h.hotel.Location == nil || h.hotel.Location.Country == nil {
+			continue
+		}
+
+		country := canonicalCountry(*h.hotel.Location.Country)
+		countries[indexKey(&country)] = true
+		conflict.Values = append(conflict.Values, dto.ConflictValue{Supplier: h.supplier, Value: *h.hotel.Location.Country})
+	}
+
+	return conflict, len(countries) > 1
+}
+
+func destinationIDConflict(hotels []supplierHotel) (dto.Conflict, bool) {
+	conflict := dto.Conflict{
+		Type:    dto.ConflictDestinationID,
+		Message: "destination ids differ",
+	}
+	destinations := map[int32]bool{}
+	for _, h := range hotels {
+		if h.hotel.DestinationID == 0 {
+			continue
+		}
+
+		destinations[h.hotel.DestinationID] = true
+		conflict.Values = append(conflict.Values, dto.ConflictValue{Supplier: h.supplier, Value: h.hotel.DestinationID})
+	}
+
+	return conflict, len(destinations) > 1
+}
+
+// Conflicts returns the hotels the suppliers disagree on, ordered by hotel id
+func (u *HotelUsecase) Conflicts(ctx context.Context, req *dto.ConflictsRequest) (*dto.ConflictsResponse, error) {
+	switch req.Type {
+	case "", dto.ConflictCoordinates, dto.ConflictName, dto.ConflictCountry, dto.ConflictDestinationID:
+	default:
+		return nil, fmt.Errorf("%w: type must be %s, %s, %s or %s", ErrInvalidRequest,
+			dto.ConflictCoordinates, dto.ConflictName, dto.ConflictCountry, dto.ConflictDestinationID)
+	}
+
+	resp := &dto.ConflictsResponse{
+		Data: []dto.HotelConflicts{},
+	}
+
+	for id, conflicts := range u.currentSnapshot(ctx).conflicts {
+		matching := []dto.Conflict{}
+		for _, conflict := range conflicts {
+			if req.Type == "" || conflict.Type == req.Type {
+				matching = append(matching, conflict)
+			}
+		}
+
+		if len(matching) > 0 {
+			resp.Data = append(resp.Data, dto.HotelConflicts{HotelID: id, Conflicts: matching})
+		}
+	}
+
+	sort.Slice(resp.Data, func(i, j int) bool {
+		return resp.Data[i].HotelID < resp.Data[j].HotelID
+	})
+	resp.Total = len(resp.Data)
+
+	return resp, nil
+}
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"hotel-data-merge/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func conflictTypes(conflicts []dto.Conflict) []string {
+	types := []string{}
+	for _, conflict := range conflicts {
+		types = append(types, conflict.Type)
+	}
+
+	return types
+}
+
+func TestConflicts(t *testing.T) {
+	ctx := context.Background()
+	point := func(lat, lng float32) *HotelLocation {
+		return &HotelLocation{Latitude: &lat, Longitude: &lng}
+	}
+	withCountry := func(location *HotelLocation, country string) *HotelLocation {
+		location.Country = &country
+		return location
+	}
+
+	conflictResults := []SupplierResult{
+		{Name: Patagonia, Priority: 1, Hotels: []Hotel{
+			{HotelID: "mbs", DestinationID: 1, Name: "Marina Bay Sands", Location: withCountry(point(1.28, 103.86), "SG")},
+			{HotelID: "same", DestinationID: 1, Name: "Pan Pacific", Location: withCountry(point(1.29, 103.86), "SG")},
+			{HotelID: "single", DestinationID: 1, Name: "Only Patagonia"},
+		}},
+		{Name: Acme, Priority: 2, Hotels: []Hotel{
+			{HotelID: "mbs", DestinationID: 2, Name: "Raffles", Location: withCountry(point(1.48, 103.86), "Japan")},
+			{HotelID: "same", DestinationID: 1, Name: "Pan Pacific Hotel", Location: withCountry(point(1.2901, 103.86), "Singapore")},
+		}},
+	}
+
+	usecase := newSnapshotUsecase(conflictResults)
+
+	t.Run("should report every field the suppliers disagree on", func(t *testing.T) {
+		resp, err := usecase.Conflicts(ctx, &dto.ConflictsRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, resp.Total)
+		assert.Equal(t, "mbs", resp.Data[0].HotelID)
+
+		conflicts := resp.Data[0].Conflicts
+		assert.Len(t, conflicts, 4)
+		assert.Equal(t, dto.ConflictCoordinates, conflicts[0].Type)
+		assert.Equal(t, "coordinates are up to 22.2 km apart", conflicts[0].Message)
+		assert.Equal(t, dto.Conflict{
+			Type:    dto.ConflictName,
+			Message: "names are only 19% similar",
+			Values: []dto.ConflictValue{
+				{Supplier: Patagonia, Value: "Marina Bay Sands"},
+				{Supplier: Acme, Value: "Raffles"},
+			},
+		}, conflicts[1])
+		assert.Equal(t, dto.Conflict{
+			Type:    dto.ConflictCountry,
+			Message: "countries differ",
+			Values: []dto.ConflictValue{
+				{Supplier: Patagonia, Value: "SG"},
+				{Supplier: Acme, Value: "Japan"},
+			},
+		}, conflicts[2])
+		assert.Equal(t, dto.Conflict{
+			Type:    dto.ConflictDestinationID,
+			Message: "destination ids differ",
+			Values: []dto.ConflictValue{
+				{Supplier: Patagonia, Value: int32(1)},
+				{Supplier: Acme, Value: int32(2)},
+			},
+		}, conflicts[3])
+	})
+
+	t.Run("should only report conflicts of the type", func(t *testing.T) {
+		resp, err := usecase.Conflicts(ctx, &dto.ConflictsRequest{Type: dto.ConflictCountry})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Data, 1)
+		assert.Len(t, resp.Data[0].Conflicts, 1)
+		assert.Equal(t, dto.ConflictCountry, resp.Data[0].Conflicts[0].Type)
+	})
+
+	t.Run("should use the configured thresholds", func(t *testing.T) {
+		cfg := DefaultConflictConfig()
+		cfg.MaxDistanceKm, cfg.MinNameSimilarity = 50, 0.9
+		cfg.Country, cfg.DestinationID = false, false
+
+		conflicts := detectConflicts(conflictResults, cfg)
+		assert.Len(t, conflicts, 2)
+		assert.Equal(t, dto.ConflictName, conflicts["mbs"][0].Type)
+		assert.Equal(t, dto.ConflictName, conflicts["same"][0].Type)
+		assert.Equal(t, "names are only 65% similar", conflicts["same"][0].Message)
+	})
+
+	t.Run("should report any coordinate difference and no names with zero thresholds", func(t *testing.T) {
+		cfg := DefaultConflictConfig()
+		cfg.MaxDistanceKm, cfg.MinNameSimilarity = 0, 0
+		cfg.Country, cfg.DestinationID = false, false
+
+		conflicts := detectConflicts(conflictResults, cfg)
+		assert.Len(t, conflicts, 2)
+		assert.Equal(t, []string{dto.ConflictCoordinates}, conflictTypes(conflicts["mbs"]))
+		assert.Equal(t, []string{dto.ConflictCoordinates}, conflictTypes(conflicts["same"]))
+	})
+
+	t.Run("should not report ignored conflicts", func(t *testing.T) {
+		cfg := DefaultConflictConfig()
+		cfg.Coordinates, cfg.Name = false, false
+
+		conflicts := detectConflicts(conflictResults, cfg)
+		assert.Len(t, conflicts, 1)
+		assert.Equal(t, []string{dto.ConflictCountry, dto.ConflictDestinationID}, conflictTypes(conflicts["mbs"]))
+	})
+
+	t.Run("should fail with an invalid type", func(t *testing.T) {
+		_, err := usecase.Conflicts(ctx, &dto.ConflictsRequest{Type: "address"})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+}
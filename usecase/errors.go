@@ -0,0 +1,10 @@
+package usecase
+
+import "errors"
+
+// errors returned to the caller, wrapped with the details of what went wrong
+var (
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrNotFound       = errors.New("not found")
+)
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"fmt"
+	"hotel-data-merge/dto"
+	"sort"
+	"strings"
+)
+
+// facetCounter counts values by their index key, so values only differing in case or accents are counted together.
+// Each key is returned with its most common spelling
+type facetCounter struct {
+	spellings map[string]map[string]int
+	counts    map[string]int
+}
+
+func newFacetCounter() *facetCounter {
+	return &facetCounter{
+		spellings: map[string]map[string]int{},
+		counts:    map[string]int{},
+	}
+}
+
+func (c *facetCounter) add(value string) {
+	key := indexKey(&value)
+	if key == "" {
+		return
+	}
+
+	if _, exists := c.spellings[key]; !exists {
+		c.spellings[key] = map[string]int{}
+	}
+	c.spellings[key][strings.TrimSpace(value)]++
+	c.counts[key]++
+}
+
+// facetCounts returns the counted values, most common first and then by value
+func (c *facetCounter) facetCounts() []dto.FacetCount {
+	counts := make([]dto.FacetCount, 0, len(c.counts))
+	for key, count := range c.counts {
+		spelling, spellingCount := "", 0
+		for value, valueCount := range c.spellings[key] {
+			if valueCount > spellingCount || (valueCount == spellingCount && value < spelling) {
+				spelling, spellingCount = value, valueCount
+			}
+		}
+
+		counts = append(counts, dto.FacetCount{Value: spelling, Count: count})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+
+	return counts
+}
+
+// hotelFacets counts the hotels by each value of the requested facets. Hotels without a value are not counted for that facet
+func hotelFacets(hotels []dto.Hotel, facets []string) *dto.Facets {
+	if len(facets) == 0 {
+		return nil
+	}
+
+	requested := map[string]bool{}
+	for _, facet := range facets {
+		requested[facet] = true
+	}
+
+	general, room := newFacetCounter(), newFacetCounter()
+	countries, cities, destinationIDs := newFacetCounter(), newFacetCounter(), newFacetCounter()
+
+	for _, hotel := range hotels {
+		if requested[dto.FacetAmenities] && hotel.Amenities != nil {
+			for _, amenity := range hotel.Amenities.GeneralAmenity {
+				general.add(amenity)
+			}
+			for _, amenity := range hotel.Amenities.RoomAmenity {
+				room.add(amenity)
+			}
+		}
+
+		if requested[dto.FacetCountry] && hotel.Location != nil && hotel.Location.Country != nil {
+			countries.add(*hotel.Location.Country)
+		}
+
+		if requested[dto.FacetCity] && hotel.Location != nil && hotel.Location.City != nil {
+			cities.add(*hotel.Location.City)
+		}
+
+		if requested[dto.FacetDestinationID] {
+			destinationIDs.add(fmt.Sprintf("%d", hotel.DestinationID))
+		}
+	}
+
+	result := &dto.Facets{}
+	if requested[dto.FacetAmenities] {
+		result.GeneralAmenities = general.facetCounts()
+		result.RoomAmenities = room.facetCounts()
+	}
+	if requested[dto.FacetCountry] {
+		result.Countries = countries.facetCounts()
+	}
+	if requested[dto.FacetCity] {
+		result.Cities = cities.facetCounts()
+	}
+	if requested[dto.FacetDestinationID] {
+		result.DestinationIDs = destinationIDs.facetCounts()
+	}
+
+	return result
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"hotel-data-merge/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListHotelsFacets(t *testing.T) {
+	ctx := context.Background()
+	sg := "SG"
+	japan := "japan"
+	singapore := "Singapore"
+	tokyo := "Tokyo"
+	lowerTokyo := "tokyo "
+	malaysia := " Malaysia"
+
+	facetHotels := []SupplierResult{
+		{Name: Acme, Hotels: []Hotel{
+			{HotelID: "mbs", DestinationID: 1, Amenities: []string{"pool", "wifi", "tv"}, Location: &HotelLocation{Country: &sg, City: &singapore}},
+			{HotelID: "sen", DestinationID: 1, Amenities: []string{"outdoor pool"}, Location: &HotelLocation{Country: &sg}},
+			{HotelID: "tyo", DestinationID: 2, Amenities: []string{"wifi", "aircon"}, Location: &HotelLocation{Country: &japan, City: &tokyo}},
+			{HotelID: "shj", DestinationID: 2, Amenities: []string{"tv"}, Location: &HotelLocation{Country: &japan, City: &lowerTokyo}},
+			{HotelID: "klc", DestinationID: 3, Location: &HotelLocation{Country: &malaysia}},
+		}},
+	}
+
+	usecase := newSnapshotUsecase(facetHotels)
+
+	t.Run("should count every facet over all hotels", func(t *testing.T) {
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{
+			Facets: []string{dto.FacetAmenities, dto.FacetCountry, dto.FacetCity, dto.FacetDestinationID},
+			Limit:  1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, &dto.Facets{
+			GeneralAmenities: []dto.FacetCount{{Value: "outdoor pool", Count: 2}, {Value: "wifi", Count: 2}},
+			RoomAmenities:    []dto.FacetCount{{Value: "tv", Count: 2}, {Value: "air conditioning", Count: 1}},
+			Countries:        []dto.FacetCount{{Value: "Japan", Count: 2}, {Value: "Singapore", Count: 2}, {Value: "Malaysia", Count: 1}},
+			Cities:           []dto.FacetCount{{Value: "Tokyo", Count: 2}, {Value: "Singapore", Count: 1}},
+			DestinationIDs:   []dto.FacetCount{{Value: "1", Count: 2}, {Value: "2", Count: 2}, {Value: "3", Count: 1}},
+		}, resp.Meta.Facets)
+	})
+
+	t.Run("should only count the filtered hotels", func(t *testing.T) {
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{
+			Amenities: []string{"wifi"},
+			Facets:    []string{dto.FacetCountry, dto.FacetCity},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, &dto.Facets{
+			Countries: []dto.FacetCount{{Value: "Japan", Count: 1}, {Value: "Singapore", Count: 1}},
+			Cities:    []dto.FacetCount{{Value: "Singapore", Count: 1}, {Value: "Tokyo", Count: 1}},
+		}, resp.Meta.Facets)
+	})
+
+	t.Run("should filter and count a country that is not in the mapping", func(t *testing.T) {
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{
+			Countries: []string{"malaysia "},
+			Facets:    []string{dto.FacetCountry},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"klc"}, hotelIDs(resp.Data))
+		assert.Equal(t, "Malaysia", *resp.Data[0].Location.Country)
+		assert.Equal(t, &dto.Facets{
+			Countries: []dto.FacetCount{{Value: "Malaysia", Count: 1}},
+		}, resp.Meta.Facets)
+	})
+
+	t.Run("should not count facets that are not requested", func(t *testing.T) {
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+		assert.NoError(t, err)
+		assert.Nil(t, resp.Meta.Facets)
+	})
+
+	t.Run("should fail with an unknown facet", func(t *testing.T) {
+		_, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Facets: []string{"price"}})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"hotel-data-merge/dto"
+	"strings"
+)
+
+// GetHotel returns the merged hotel with the id, with the hotel of every supplier and where every field came from if included
+func (u *HotelUsecase) GetHotel(ctx context.Context, req *dto.GetHotelRequest) (*dto.GetHotelResponse, error) {
+	id := strings.TrimSpace(req.HotelID)
+	if id == "" {
+		return nil, fmt.Errorf("%w: hotel id is required", ErrInvalidRequest)
+	}
+
+	includeSources, includeProvenance := false, false
+	for _, include := range req.Include {
+		switch strings.TrimSpace(include) {
+		case dto.IncludeSources:
+			includeSources = true
+		case dto.IncludeProvenance:
+			includeProvenance = true
+		default:
+			return nil, fmt.Errorf("%w: include must be %s or %s", ErrInvalidRequest, dto.IncludeSources, dto.IncludeProvenance)
+		}
+	}
+
+	snapshot := u.currentSnapshot(ctx)
+	hotel, ok := snapshot.hotel(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: hotel %s", ErrNotFound, id)
+	}
+
+	resp := &dto.GetHotelResponse{
+		Data: hotel,
+		Meta: responseMeta(snapshot.sources),
+	}
+
+	if includeSources {
+		resp.Sources = snapshot.supplierHotels[id]
+	}
+
+	if includeProvenance {
+		resp.Provenance = dto.Provenance(snapshot.provenance[id])
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"hotel-data-merge/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHotel(t *testing.T) {
+	ctx := context.Background()
+	address := " 10 Bayfront Ave "
+	singapore := "Singapore"
+
+	acmeHotel := Hotel{
+		HotelID:   "mbs",
+		Name:      "Marina Bay Sands",
+		Location:  &HotelLocation{City: &singapore},
+		Amenities: []string{"Pool"},
+	}
+	patagoniaHotel := Hotel{
+		HotelID:   "mbs",
+		Name:      "Marina Bay Sands Hotel",
+		Location:  &HotelLocation{Address: &address},
+		Amenities: []string{"WiFi"},
+	}
+
+	usecase := newSnapshotUsecase([]SupplierResult{
+		{Name: Acme, Hotels: []Hotel{acmeHotel}},
+		{Name: Patagonia, Hotels: []Hotel{patagoniaHotel}},
+	})
+
+	t.Run("should return the merged hotel", func(t *testing.T) {
+		resp, err := usecase.GetHotel(ctx, &dto.GetHotelRequest{HotelID: "mbs"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Marina Bay Sands Hotel", resp.Data.Name)
+		assert.Equal(t, "10 Bayfront Ave", *resp.Data.Location.Address)
+		assert.Equal(t, "Singapore", *resp.Data.Location.City)
+		assert.Nil(t, resp.Sources)
+		assert.Len(t, resp.Meta.Sources, 2)
+	})
+
+	t.Run("should return the hotel of every supplier as it was before the merge", func(t *testing.T) {
+		resp, err := usecase.GetHotel(ctx, &dto.GetHotelRequest{HotelID: "mbs", Include: []string{dto.IncludeSources}})
+		assert.NoError(t, err)
+		assert.Equal(t, []dto.SupplierHotel{
+			{
+				Supplier: Acme,
+				Hotel: dto.SourceHotel{
+					HotelID:   "mbs",
+					Name:      "Marina Bay Sands",
+					Location:  &dto.HotelLocation{City: &singapore},
+					Amenities: []string{"Pool"},
+				},
+			},
+			{
+				Supplier: Patagonia,
+				Hotel: dto.SourceHotel{
+					HotelID:   "mbs",
+					Name:      "Marina Bay Sands Hotel",
+					Location:  &dto.HotelLocation{Address: &address},
+					Amenities: []string{"WiFi"},
+				},
+			},
+		}, resp.Sources)
+	})
+
+	t.Run("should return where every merged field came from", func(t *testing.T) {
+		resp, err := usecase.GetHotel(ctx, &dto.GetHotelRequest{HotelID: "mbs", Include: []string{dto.IncludeProvenance}})
+		assert.NoError(t, err)
+		assert.Equal(t, []dto.FieldProvenance{{Supplier: Patagonia, Value: "Marina Bay Sands Hotel", Rule: StrategyLongest}}, resp.Provenance[FieldName])
+		assert.Equal(t, []dto.FieldProvenance{{Supplier: Patagonia, Value: " 10 Bayfront Ave ", Rule: StrategyFirstNonNil}}, resp.Provenance[FieldAddress])
+		assert.Equal(t, []dto.FieldProvenance{{Supplier: Acme, Value: "Singapore", Rule: StrategyFirstNonNil}}, resp.Provenance[FieldCity])
+		assert.ElementsMatch(t, []dto.FieldProvenance{
+			{Supplier: Acme, Value: []string{"Pool"}, Rule: StrategyAppend},
+			{Supplier: Patagonia, Value: []string{"WiFi"}, Rule: StrategyAppend},
+		}, resp.Provenance[FieldAmenities])
+		assert.NotContains(t, resp.Provenance, FieldDescription)
+		assert.Nil(t, resp.Sources)
+	})
+
+	t.Run("should return the provenance of every listed hotel", func(t *testing.T) {
+		hotel, err := usecase.GetHotel(ctx, &dto.GetHotelRequest{HotelID: "mbs", Include: []string{dto.IncludeProvenance}})
+		assert.NoError(t, err)
+
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Include: []string{dto.IncludeProvenance}})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]dto.Provenance{"mbs": hotel.Provenance}, resp.Provenance)
+
+		resp, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+		assert.NoError(t, err)
+		assert.Nil(t, resp.Provenance)
+
+		_, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{Include: []string{dto.IncludeSources}})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+
+	t.Run("should fail if the hotel does not exist", func(t *testing.T) {
+		_, err := usecase.GetHotel(ctx, &dto.GetHotelRequest{HotelID: "unknown"})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("should fail with an invalid request", func(t *testing.T) {
+		_, err := usecase.GetHotel(ctx, &dto.GetHotelRequest{HotelID: " "})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+
+		_, err = usecase.GetHotel(ctx, &dto.GetHotelRequest{HotelID: "mbs", Include: []string{"reviews"}})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+}
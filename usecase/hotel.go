@@ -5,149 +5,253 @@ import (
 	"fmt"
 	"hotel-data-merge/dto"
 	"hotel-data-merge/pkg/cache"
+	"hotel-data-merge/pkg/singleflight"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type HotelRepository interface {
-	ListHotels(ctx context.Context) map[string][]Hotel
+	ListHotels(ctx context.Context) []SupplierResult
+	FetchSupplier(ctx context.Context, name string) SupplierResult
+	SupplierHealth() []SupplierHealth
+}
+
+// CacheConfig controls how long the supplier results fetched on a cache miss are served
+type CacheConfig struct {
+	SoftTTL time.Duration // after this the cached results are still served but refreshed in the background
+	HardTTL time.Duration // after this the cached results expire and requests wait for a refresh
 }
 
 type HotelUsecase struct {
-	hotelRepo HotelRepository
-	cache     cache.CacheInterface
+	hotelRepo   HotelRepository
+	cache       cache.CacheInterface
+	cacheConfig CacheConfig
+	mergeRules  MergeRules
+
+	// refreshGroup makes sure only one refresh of the cached results runs at a time
+	refreshGroup singleflight.Group
+	refreshing   int32
+
+	// snapshot holds the hotels prepared by the background refresh, nil if it has not run
+	refreshMutex  sync.Mutex
+	snapshotMutex sync.RWMutex
+	snapshot      *HotelSnapshot
+	// fetchStartedAt holds when the fetch of the result of every supplier in the snapshot started, guarded by refreshMutex
+	fetchStartedAt map[string]time.Time
 }
 
-func NewHotelUsecase(repo HotelRepository, cache cache.CacheInterface) *HotelUsecase {
+func NewHotelUsecase(repo HotelRepository, cache cache.CacheInterface, cacheConfig CacheConfig, mergeRules MergeRules) *HotelUsecase {
 	return &HotelUsecase{
-		hotelRepo: repo,
-		cache:     cache,
+		hotelRepo:   repo,
+		cache:       cache,
+		cacheConfig: cacheConfig,
+		mergeRules:  mergeRules,
+
+		fetchStartedAt: map[string]time.Time{},
 	}
 }
 
+// cachedHotelSnapshot is stored in the cache with the time it was fetched, to tell if it is past the soft ttl
+type cachedHotelSnapshot struct {
+	Snapshot  *HotelSnapshot
+	FetchedAt time.Time
+}
+
 const (
-	GroupByDestination = "destination"
-	GroupByHotel       = "hotel"
-	CacheKey           = "hotels-cache-key"
+	CacheKey = "hotels-cache-key"
+	// LastKnownGoodCacheKey is the prefix of the cache keys holding the last successful result of each supplier
+	LastKnownGoodCacheKey = "hotels-last-known-good"
 )
 
-func (u *HotelUsecase) ListHotels(ctx context.Context, req *dto.ListHotelsRequest) *dto.ListHotelsResponse {
-	var mergedHotels map[string]Hotel
-	var filteredIds []string
-	var hotelsFromExternal map[string][]Hotel
+func lastKnownGoodCacheKey(supplier string) string {
+	return fmt.Sprintf("%s:%s", LastKnownGoodCacheKey, supplier)
+}
 
-	// filterType := GroupByDestination
-	filteredIds = req.DestinationIDs
+func (u *HotelUsecase) ListHotels(ctx context.Context, req *dto.ListHotelsRequest) (*dto.ListHotelsResponse, error) {
+	if err := validateListHotelsRequest(req); err != nil {
+		return nil, err
+	}
 
-	// hotel id takes precedence for filter because it is more specific
-	if len(req.HotelIDs) > 0 {
-		filteredIds = req.HotelIDs
-		// filterType = GroupByHotel
+	snapshot := u.currentSnapshot(ctx)
+	hotels, scores := snapshot.filterHotels(req)
+	page, nextCursor, err := paginate(sortHotels(hotels, scores, req), req)
+	if err != nil {
+		return nil, err
 	}
 
-	cacheVal, ok := u.cache.Get(CacheKey)
+	meta := responseMeta(snapshot.sources)
+	meta.Facets = hotelFacets(hotels, req.Facets)
 
-	if ok {
-		hotelsFromExternal = cacheVal.(map[string][]Hotel)
-	} else {
-		hotelsFromExternal = u.hotelRepo.ListHotels(ctx)
+	resp := &dto.ListHotelsResponse{
+		Data:       page,
+		Total:      len(hotels),
+		NextCursor: nextCursor,
+		Meta:       meta,
+	}
 
-		// this highly depends on how often the data changes
-		u.cache.Set(CacheKey, hotelsFromExternal, 60*time.Minute)
+	// include only takes provenance, which is checked by the validation
+	if len(req.Include) > 0 {
+		resp.Provenance = make(map[string]dto.Provenance, len(page))
+		for _, hotel := range page {
+			resp.Provenance[hotel.HotelID] = dto.Provenance(snapshot.provenance[hotel.HotelID])
+		}
 	}
 
-	mergedHotels = mergeHotelByID(hotelsFromExternal)
+	return resp, nil
+}
 
-	hotelPartition := hotelPartitioning(mergedHotels)
+// currentSnapshot reads the snapshot prepared by the background refresh, and only fetches from the suppliers if there is none
+func (u *HotelUsecase) currentSnapshot(ctx context.Context) *HotelSnapshot {
+	snapshot, ok := u.hotelSnapshot()
+	if !ok {
+		snapshot = u.cachedHotelSnapshot(ctx)
+	}
 
-	// return all hotels if there is no filter
-	// filteredHotels := filterHotels(filterType, filteredIds, mergedHotels)
-	filteredHotels := filterHotelsV2(filteredIds, hotelPartition)
+	return snapshot
+}
 
-	// add pagination here. page and limit
-	cleanedHotels := cleanMergedData(filteredHotels)
+// cachedHotelSnapshot returns the hotel snapshot from the cache. A snapshot past the soft ttl is returned
+// while it is refreshed in the background. On a cache miss, concurrent requests wait for a single refresh
+func (u *HotelUsecase) cachedHotelSnapshot(ctx context.Context) *HotelSnapshot {
+	cacheVal, ok := u.cache.Get(CacheKey)
+	if ok {
+		cached := cacheVal.(cachedHotelSnapshot)
+		if time.Since(cached.FetchedAt) >= u.cacheConfig.SoftTTL && atomic.CompareAndSwapInt32(&u.refreshing, 0, 1) {
+			go func() {
+				defer atomic.StoreInt32(&u.refreshing, 0)
+				u.refreshCachedHotelSnapshot(context.Background())
+			}()
+		}
 
-	return &dto.ListHotelsResponse{
-		Data: cleanedHotels,
+		return cached.Snapshot
 	}
+
+	return u.refreshCachedHotelSnapshot(ctx)
 }
 
-// map[string]Hotel -> map of the different id and the hotel detail
-func hotelPartitioning(hotels map[string]Hotel) map[string]map[string][]Hotel {
-	hotelPartition := map[string]map[string][]Hotel{}
+func (u *HotelUsecase) refreshCachedHotelSnapshot(ctx context.Context) *HotelSnapshot {
+	val, _, _ := u.refreshGroup.Do(CacheKey, func() (interface{}, error) {
+		supplierResults := u.withLastKnownGood(u.hotelRepo.ListHotels(ctx))
+		snapshot := buildSnapshot(supplierResults, u.mergeRules)
+
+		// results missing a supplier are not cached so that the supplier is retried on the next request
+		// instead of missing for the whole cache duration. Failed suppliers with last known good data are not missing
+		if !hasMissingSupplier(supplierResults) {
+			u.cache.Set(CacheKey, cachedHotelSnapshot{
+				Snapshot:  snapshot,
+				FetchedAt: time.Now(),
+			}, u.cacheConfig.HardTTL)
+		}
 
-	for _, hotel := range hotels {
-		partitionKeyHotelID := string(hotel.HotelID[0])
-		partitionKeyDestinationID := string(fmt.Sprintf("%d", hotel.DestinationID)[0])
-
-		if _, exists := hotelPartition[partitionKeyHotelID]; exists {
-			hotelPartition[partitionKeyHotelID][hotel.HotelID] = append(hotelPartition[partitionKeyHotelID][hotel.HotelID], hotel)
-		} else {
-			hotelPartition[partitionKeyHotelID] = map[string][]Hotel{
-				hotel.HotelID: {hotel},
-			}
+		return snapshot, nil
+	})
+
+	return val.(*HotelSnapshot)
+}
+
+// Health returns the state of every supplier
+func (u *HotelUsecase) Health(ctx context.Context) *dto.HealthResponse {
+	resp := &dto.HealthResponse{
+		Suppliers: []dto.SupplierHealth{},
+	}
+
+	for _, health := range u.hotelRepo.SupplierHealth() {
+		supplier := dto.SupplierHealth{
+			Name:                health.Name,
+			CircuitState:        health.CircuitState,
+			ConsecutiveFailures: health.ConsecutiveFailures,
 		}
 
-		if _, exists := hotelPartition[partitionKeyDestinationID]; exists {
-			hotelPartition[partitionKeyDestinationID][fmt.Sprintf("%d", hotel.DestinationID)] = append(hotelPartition[partitionKeyDestinationID][fmt.Sprintf("%d", hotel.DestinationID)], hotel)
-		} else {
-			hotelPartition[partitionKeyDestinationID] = map[string][]Hotel{
-				fmt.Sprintf("%d", hotel.DestinationID): {hotel},
-			}
+		if !health.OpenedAt.IsZero() {
+			openedAt := health.OpenedAt
+			supplier.OpenedAt = &openedAt
 		}
+
+		resp.Suppliers = append(resp.Suppliers, supplier)
 	}
 
-	return hotelPartition
+	return resp
 }
 
-func filterHotelsV2(ids []string, hotelPartioning map[string]map[string][]Hotel) map[string]Hotel {
-	idsToInclude := map[string]bool{}
-	for _, id := range ids {
-		idsToInclude[id] = true
-	}
+// withLastKnownGood stores the result of every successful supplier, and replaces the result of every failed supplier
+// with its last successful result if there is one
+func (u *HotelUsecase) withLastKnownGood(results []SupplierResult) []SupplierResult {
+	merged := make([]SupplierResult, 0, len(results))
+
+	for _, result := range results {
+		key := lastKnownGoodCacheKey(result.Name)
+		if result.Err == nil {
+			u.cache.Set(key, result, cache.NoExpiration)
+			merged = append(merged, result)
+			continue
+		}
 
-	filteredHotels := map[string]Hotel{}
+		cacheVal, ok := u.cache.Get(key)
+		if !ok {
+			merged = append(merged, result)
+			continue
+		}
 
-	for _, id := range ids {
-		partitionKey := string(id[0])
-		hotelsInPartition := hotelPartioning[partitionKey]
-		fmt.Println(hotelsInPartition)
-		// filteredHotels[id] = hotelsInPartition[id]
+		lastKnownGood := cacheVal.(SupplierResult)
+		result.Hotels = lastKnownGood.Hotels
+		result.RecordCount = lastKnownGood.RecordCount
+		result.FetchedAt = lastKnownGood.FetchedAt
+		result.Stale = true
+		merged = append(merged, result)
 	}
 
-	return filteredHotels
+	return merged
 }
 
-func filterHotels(filterType string, ids []string, mergedHotels map[string]Hotel) map[string]Hotel {
-	if len(ids) == 0 {
-		return mergedHotels
+// hasMissingSupplier returns if a supplier failed without last known good data to replace its hotels
+func hasMissingSupplier(results []SupplierResult) bool {
+	for _, result := range results {
+		if result.Err != nil && !result.Stale {
+			return true
+		}
 	}
 
-	idsToInclude := map[string]bool{}
-	for _, id := range ids {
-		idsToInclude[id] = true
+	return false
+}
+
+// responseMeta reports the outcome of every supplier so callers can tell if the hotels are incomplete
+func responseMeta(results []SupplierResult) *dto.ResponseMeta {
+	meta := &dto.ResponseMeta{
+		Sources: []dto.SourceMeta{},
 	}
 
-	filteredHotels := map[string]Hotel{}
+	for _, result := range results {
+		source := dto.SourceMeta{
+			Name:        result.Name,
+			Status:      dto.SourceStatusOK,
+			LatencyMs:   result.Latency.Milliseconds(),
+			HTTPStatus:  result.StatusCode,
+			Attempts:    result.Attempts,
+			NotModified: result.NotModified,
+			RecordCount: result.RecordCount,
+		}
 
-	if filterType == GroupByHotel {
-		for id, hotelDetails := range mergedHotels {
-			if _, exists := idsToInclude[id]; exists {
-				filteredHotels[id] = hotelDetails
-			}
+		if result.Err != nil {
+			source.Status = dto.SourceStatusError
+			source.Error = result.Err.Error()
 		}
 
-		return filteredHotels
-	}
+		if result.Stale {
+			source.Status = dto.SourceStatusStale
+			source.StaleAgeSeconds = int64(time.Since(result.FetchedAt).Seconds())
+		}
 
-	for id, hotelDetails := range mergedHotels {
-		descIDstr := fmt.Sprintf("%d", hotelDetails.DestinationID)
-		if _, exists := idsToInclude[descIDstr]; exists {
-			filteredHotels[id] = hotelDetails
+		if source.Status == dto.SourceStatusError {
+			meta.Partial = true
 		}
+
+		meta.Sources = append(meta.Sources, source)
 	}
 
-	return filteredHotels
+	return meta
 }
 
 // cleanMergedData cleans the data and presents it in the api format we want to return
@@ -175,120 +279,56 @@ func cleanMergedData(hotels map[string]Hotel) []dto.Hotel {
 		cleanedHotels = append(cleanedHotels, cleanedHotel)
 	}
 
-	return cleanedHotels
-}
-
-// mergeHotelByID merges all 3 hotel sources data and groups them by hotel id
-func mergeHotelByID(sources map[string][]Hotel) map[string]Hotel {
-	mergedHotels := make(map[string]Hotel)
-
-	for _, source := range sources {
-		for _, hotel := range source {
-			id := hotel.HotelID
-			existingHotel, exists := mergedHotels[id]
-
-			if !exists {
-				mergedHotels[id] = hotel
-				continue
-			}
-
-			if existingHotel.Location.Address == nil && hotel.Location.Address != nil {
-				existingHotel.Location.Address = hotel.Location.Address
-			}
-
-			if existingHotel.Location.Latitude == nil && hotel.Location.Latitude != nil {
-				existingHotel.Location.Latitude = hotel.Location.Latitude
-			}
-
-			if existingHotel.Location.Longitude == nil && hotel.Location.Longitude != nil {
-				existingHotel.Location.Longitude = hotel.Location.Longitude
-			}
+	// the merged hotels are in a map, so they are sorted to always be returned in the same order
+	sort.Slice(cleanedHotels, func(i, j int) bool {
+		return cleanedHotels[i].HotelID < cleanedHotels[j].HotelID
+	})
 
-			if existingHotel.Location.Country == nil && hotel.Location.Country != nil {
-				existingHotel.Location.Country = hotel.Location.Country
-			}
-
-			if existingHotel.Location.City == nil && hotel.Location.City != nil {
-				existingHotel.Location.City = hotel.Location.City
-			}
-
-			existingHotel.BookingConditions = append(existingHotel.BookingConditions, hotel.BookingConditions...)
-
-			// choosing name based on length. but we can implement other scoring systems such as relevancy scoring
-			if len(hotel.Name) > len(existingHotel.Name) {
-				existingHotel.Name = hotel.Name
-			}
-
-			// choosing description based on length. but we can implement other scoring systems such as sentiments and relevancy scoring
-			if len(hotel.Description) > len(existingHotel.Description) {
-				existingHotel.Description = hotel.Description
-			}
-
-			// combining all the amenities and images first, will do normalization and removing of duplicates later
-			existingHotel.Amenities = append(existingHotel.Amenities, hotel.Amenities...)
-
-			if existingHotel.Images == nil {
-				existingHotel.Images = hotel.Images
-			} else if hotel.Images != nil {
-				existingHotel.Images.AmmenityImages = append(existingHotel.Images.AmmenityImages, hotel.Images.AmmenityImages...)
-				existingHotel.Images.SiteImages = append(existingHotel.Images.SiteImages, hotel.Images.SiteImages...)
-				existingHotel.Images.RoomImages = append(existingHotel.Images.RoomImages, hotel.Images.RoomImages...)
-			}
-
-			mergedHotels[id] = existingHotel
-		}
-	}
-
-	return mergedHotels
+	return cleanedHotels
 }
 
-// groupImages groups the images together and removes duplicate images based on link and caption
+// groupImages groups the images together and removes duplicate images based on link.
+// An image linked more than once keeps the longest description, or the first alphabetically on a tie,
+// and the images are sorted by link so they do not depend on the order of the suppliers
 func groupImages(images *HotelImages) *dto.HotelImages {
-	cleanedImages := &dto.HotelImages{}
-
-	amenityImages := map[string]string{}
-	for _, image := range images.AmmenityImages {
-		amenityImages[image.Link] = image.Description
+	if images == nil {
+		return nil
 	}
 
-	siteImages := map[string]string{}
-	for _, image := range images.SiteImages {
-		siteImages[image.Link] = image.Description
-	}
-
-	roomImages := map[string]string{}
-	for _, image := range images.RoomImages {
-		roomImages[image.Link] = image.Description
+	return &dto.HotelImages{
+		AmmenityImages: uniqueImages(images.AmmenityImages),
+		SiteImages:     uniqueImages(images.SiteImages),
+		RoomImages:     uniqueImages(images.RoomImages),
 	}
+}
 
-	for link, desc := range amenityImages {
-		cleanedImages.AmmenityImages = append(cleanedImages.AmmenityImages, dto.HotelImage{
-			Link:        link,
-			Description: desc,
-		})
+func uniqueImages(images []HotelImage) []dto.HotelImage {
+	descriptions := map[string]string{}
+	for _, image := range images {
+		desc, exists := descriptions[image.Link]
+		if !exists || len(image.Description) > len(desc) || (len(image.Description) == len(desc) && image.Description < desc) {
+			descriptions[image.Link] = image.Description
+		}
 	}
 
-	for link, desc := range siteImages {
-		cleanedImages.SiteImages = append(cleanedImages.SiteImages, dto.HotelImage{
+	var unique []dto.HotelImage
+	for link, desc := range descriptions {
+		unique = append(unique, dto.HotelImage{
 			Link:        link,
 			Description: desc,
 		})
 	}
 
-	for link, desc := range roomImages {
-		cleanedImages.RoomImages = append(cleanedImages.RoomImages, dto.HotelImage{
-			Link:        link,
-			Description: desc,
-		})
-	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].Link < unique[j].Link
+	})
 
-	return cleanedImages
+	return unique
 }
 
-// groupAmenity normalizes all the ammenties by ensuring they return the same amenity name,
-// cleans the data (eg. trimspace) and remove any duplicates
-func groupAmenity(amenities []string) *dto.HotelAmenity {
-	generalAmenity := map[string]string{
+// generalAmenities and roomAmenities map the amenity names of the suppliers to the canonical amenity names
+var (
+	generalAmenities = map[string]string{
 		"pool":            "outdoor pool",
 		"indoor pool":     "indoor pool",
 		"outdoor pool":    "outdoor pool",
@@ -305,7 +345,7 @@ func groupAmenity(amenities []string) *dto.HotelAmenity {
 		"bar":             "bar",
 	}
 
-	roomAmenity := map[string]string{
+	roomAmenities = map[string]string{
 		"aircon":         "air conditioning",
 		"tv":             "tv",
 		"coffee machine": "coffee machine",
@@ -316,25 +356,41 @@ func groupAmenity(amenities []string) *dto.HotelAmenity {
 		"bathtub":        "bath tub",
 		"minibar":        "minibar",
 	}
+)
+
+// canonicalAmenity returns the canonical name of the amenity, or the cleaned amenity if it is not in the mapping
+func canonicalAmenity(amenity string) string {
+	amenity = strings.TrimSpace(strings.ToLower(amenity))
+
+	if val, exists := generalAmenities[amenity]; exists {
+		return val
+	}
 
+	if val, exists := roomAmenities[amenity]; exists {
+		return val
+	}
+
+	return amenity
+}
+
+// groupAmenity normalizes all the ammenties by ensuring they return the same amenity name,
+// cleans the data (eg. trimspace) and remove any duplicates
+func groupAmenity(amenities []string) *dto.HotelAmenity {
 	general := map[string]bool{}
 	room := map[string]bool{}
 
 	for _, amenity := range amenities {
 		amenity = strings.TrimSpace(strings.ToLower(amenity))
 
-		if val, exists := generalAmenity[amenity]; exists {
+		if val, exists := generalAmenities[amenity]; exists {
 			general[val] = true
 			continue
 		}
 
-		if val, exists := roomAmenity[amenity]; exists {
+		if val, exists := roomAmenities[amenity]; exists {
 			room[val] = true
-			continue
 		}
-
-		// if the amenity does not exist, we can log it here and update the list of amenities accordingly
-		fmt.Printf("amenity not in mapping: %s", amenity)
+		// amenities that are not in the mapping are left out, the mapping can be updated to include them
 	}
 
 	hotelAmenity := &dto.HotelAmenity{}
@@ -347,20 +403,37 @@ func groupAmenity(amenities []string) *dto.HotelAmenity {
 		hotelAmenity.RoomAmenity = append(hotelAmenity.RoomAmenity, key)
 	}
 
+	// sorted so the amenities do not depend on the order of the suppliers
+	sort.Strings(hotelAmenity.GeneralAmenity)
+	sort.Strings(hotelAmenity.RoomAmenity)
+
 	return hotelAmenity
 }
 
+// countryNames maps the country names and codes of the suppliers to the country name we return
+var countryNames = map[string]string{
+	"singapore": "Singapore",
+	"japan":     "Japan",
+	"sg":        "Singapore",
+	"jp":        "Japan",
+}
+
 // cleanCountryName parses the country name and returns a consistent value
 func cleanCountryName(country *string) *string {
-	countryMap := map[string]string{
-		"singapore": "Singapore",
-		"japan":     "Japan",
-		"sg":        "Singapore",
-		"jp":        "Japan",
+	if country == nil {
+		return nil
 	}
 
-	c := strings.TrimSpace(strings.ToLower(*country))
-	cleanedCountry := countryMap[c]
-
+	cleanedCountry := canonicalCountry(*country)
 	return &cleanedCountry
 }
+
+// canonicalCountry returns the country name we return for the country, or the trimmed country if it is not in the mapping
+func canonicalCountry(country string) string {
+	country = strings.TrimSpace(country)
+	if val, exists := countryNames[strings.ToLower(country)]; exists {
+		return val
+	}
+
+	return country
+}
@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"hotel-data-merge/dto"
+	"hotel-data-merge/pkg/geo"
+	"hotel-data-merge/pkg/search"
+	"strconv"
+	"strings"
+)
+
+// hotelIndex holds inverted indexes from the filterable fields to the positions of the hotels in the snapshot,
+// so a lookup only touches the hotels it returns
+type hotelIndex struct {
+	byHotelID       map[string]int
+	byDestinationID map[int32][]int
+	byCountry       map[string][]int
+	byCity          map[string][]int
+	byAmenity       map[string][]int
+	// geo holds the hotels that have coordinates
+	geo *geo.Grid
+	// text holds the words of the name, description, address and amenities of the hotels
+	text *search.Index
+	// suggestions holds the hotel names and cities to complete prefixes with
+	suggestions *search.Suggester
+}
+
+// weights of the hotel fields in the text index, a word in the name counts as much as it appearing 3 times elsewhere
+const (
+	nameWeight  = 3
+	otherWeight = 1
+)
+
+// gridCellSize is the size of the spatial index cells in degrees, around 55km at the equator
+const gridCellSize = 0.5
+
+// newHotelIndex indexes the cleaned hotels. Country, city and amenity keys are lower cased
+func newHotelIndex(hotels []dto.Hotel) hotelIndex {
+	index := hotelIndex{
+		byHotelID:       make(map[string]int, len(hotels)),
+		byDestinationID: map[int32][]int{},
+		byCountry:       map[string][]int{},
+		byCity:          map[string][]int{},
+		byAmenity:       map[string][]int{},
+		geo:             geo.NewGrid(gridCellSize),
+		text:            search.NewIndex(),
+		suggestions:     search.NewSuggester(),
+	}
+
+	for i, hotel := range hotels {
+		index.byHotelID[hotel.HotelID] = i
+		index.byDestinationID[hotel.DestinationID] = append(index.byDestinationID[hotel.DestinationID], i)
+
+		if hotel.Location != nil {
+			if key := indexKey(hotel.Location.Country); key != "" {
+				index.byCountry[key] = append(index.byCountry[key], i)
+			}
+			if key := indexKey(hotel.Location.City); key != "" {
+				index.byCity[key] = append(index.byCity[key], i)
+			}
+		}
+
+		if point, ok := hotelPoint(hotel); ok && point.Validate() == nil {
+			index.geo.Add(i, point)
+		}
+
+		index.text.Add(i, textFields(hotel)...)
+		index.suggestions.Add(hotel.Name, dto.SuggestionTypeHotel, hotel.HotelID)
+		if hotel.Location != nil && hotel.Location.City != nil {
+			index.suggestions.Add(*hotel.Location.City, dto.SuggestionTypeCity, "")
+		}
+
+		for _, amenity := range hotelAmenities(hotel) {
+			key := indexKey(&amenity)
+			index.byAmenity[key] = append(index.byAmenity[key], i)
+		}
+	}
+
+	index.suggestions.Build()
+
+	return index
+}
+
+func textFields(hotel dto.Hotel) []search.Field {
+	fields := []search.Field{
+		{Text: hotel.Name, Weight: nameWeight},
+		{Text: hotel.Description, Weight: otherWeight},
+	}
+
+	if hotel.Location != nil && hotel.Location.Address != nil {
+		fields = append(fields, search.Field{Text: *hotel.Location.Address, Weight: otherWeight})
+	}
+
+	for _, amenity := range hotelAmenities(hotel) {
+		fields = append(fields, search.Field{Text: amenity, Weight: otherWeight})
+	}
+
+	return fields
+}
+
+// hotelAmenities returns the general and room amenities of the hotel
+func hotelAmenities(hotel dto.Hotel) []string {
+	if hotel.Amenities == nil {
+		return nil
+	}
+
+	amenities := make([]string, 0, len(hotel.Amenities.GeneralAmenity)+len(hotel.Amenities.RoomAmenity))
+	amenities = append(amenities, hotel.Amenities.GeneralAmenity...)
+	return append(amenities, hotel.Amenities.RoomAmenity...)
+}
+
+// indexKey folds the value so that keys differing in case, accents or surrounding spaces are the same
+func indexKey(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(search.Fold(*value))
+}
+
+// hotelIDs returns the positions of the hotels with the given ids, in the order of the ids. Unknown and repeated ids are skipped
+func (i hotelIndex) hotelIDs(ids []string) []int {
+	positions := []int{}
+	seen := map[int]bool{}
+
+	for _, id := range ids {
+		position, exists := i.byHotelID[strings.TrimSpace(id)]
+		if !exists || seen[position] {
+			continue
+		}
+
+		seen[position] = true
+		positions = append(positions, position)
+	}
+
+	return positions
+}
+
+// destinationIDs returns the positions of the hotels in any of the given destinations.
+// Unknown and non numeric destination ids are skipped
+func (i hotelIndex) destinationIDs(ids []string) []int {
+	positions := []int{}
+	seen := map[int32]bool{}
+
+	for _, id := range ids {
+		destinationID, err := strconv.ParseInt(strings.TrimSpace(id), 10, 32)
+		if err != nil || seen[int32(destinationID)] {
+			continue
+		}
+
+		seen[int32(destinationID)] = true
+		positions = append(positions, i.byDestinationID[int32(destinationID)]...)
+	}
+
+	return positions
+}
+
+// countries returns the positions of the hotels in any of the countries, matched by their cleaned country name
+func (i hotelIndex) countries(countries []string) []int {
+	lists := [][]int{}
+	for _, country := range uniqueKeys(countries, canonicalCountry) {
+		lists = append(lists, i.byCountry[country])
+	}
+
+	return combinePositions(lists, true)
+}
+
+// cities returns the positions of the hotels in any of the cities
+func (i hotelIndex) cities(cities []string) []int {
+	lists := [][]int{}
+	for _, city := range uniqueKeys(cities, nil) {
+		lists = append(lists, i.byCity[city])
+	}
+
+	return combinePositions(lists, true)
+}
+
+// amenities returns the positions of the hotels with all of the amenities, or any of them if any is set.
+// The amenities are matched by their canonical name
+func (i hotelIndex) amenities(amenities []string, any bool) []int {
+	lists := [][]int{}
+	for _, amenity := range uniqueKeys(amenities, canonicalAmenity) {
+		lists = append(lists, i.byAmenity[amenity])
+	}
+
+	return combinePositions(lists, any)
+}
+
+// uniqueKeys returns the index keys of the values, canonicalized first if canonical is set
+func uniqueKeys(values []string, canonical func(string) string) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, value := range values {
+		if canonical != nil {
+			value = canonical(value)
+		}
+
+		key := indexKey(&value)
+		if key == "" || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// near returns the positions of the hotels within the radius of the point. Hotels without coordinates are never returned
+func (i hotelIndex) near(point geo.Point, radiusKm float64) []int {
+	return i.geo.WithinRadius(point, radiusKm)
+}
+
+// boundingBox returns the positions of the hotels within the box. Hotels without coordinates are never returned
+func (i hotelIndex) boundingBox(box geo.BoundingBox) []int {
+	return i.geo.WithinBox(box)
+}
+
+// search returns the hotels matching every word of the query with their relevance score
+func (i hotelIndex) search(query string) []search.Match {
+	return i.text.Search(query)
+}
+
+// suggest returns the hotel names and cities starting with the prefix, allowing a typo
+func (i hotelIndex) suggest(prefix string, limit int) []search.Suggestion {
+	return i.suggestions.Suggest(prefix, limit)
+}
@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"context"
+	"hotel-data-merge/dto"
+	"hotel-data-merge/pkg/geo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHotelIndex(t *testing.T) {
+	singapore := "Singapore"
+	japan := "Japan"
+	tokyo := "Tokyo"
+
+	// hotel ids starting with a digit used to collide with destination ids in the same partition
+	hotels := []dto.Hotel{
+		{HotelID: "iJhz", DestinationID: 5432, Location: &dto.HotelLocation{Country: &singapore}, Amenities: &dto.HotelAmenity{GeneralAmenity: []string{"wifi"}}},
+		{HotelID: "SjyX", DestinationID: 5432, Location: &dto.HotelLocation{Country: &singapore}, Amenities: &dto.HotelAmenity{RoomAmenity: []string{"tv"}}},
+		{HotelID: "f8c9", DestinationID: 1122, Location: &dto.HotelLocation{Country: &japan, City: &tokyo}, Amenities: &dto.HotelAmenity{GeneralAmenity: []string{"wifi"}}},
+		{HotelID: "5432", DestinationID: 1122, Location: &dto.HotelLocation{}},
+	}
+	index := newHotelIndex(hotels)
+
+	hotelIDTests := []struct {
+		name     string
+		ids      []string
+		expected []int
+	}{
+		{name: "single id", ids: []string{"SjyX"}, expected: []int{1}},
+		{name: "mixed ids in request order", ids: []string{"f8c9", "iJhz"}, expected: []int{2, 0}},
+		{name: "id that looks like a destination id", ids: []string{"5432"}, expected: []int{3}},
+		{name: "unknown ids are skipped", ids: []string{"unknown", "iJhz", ""}, expected: []int{0}},
+		{name: "only unknown ids", ids: []string{"unknown"}, expected: []int{}},
+		{name: "repeated ids are returned once", ids: []string{"iJhz", " iJhz"}, expected: []int{0}},
+		{name: "ids are case sensitive", ids: []string{"ijhz"}, expected: []int{}},
+	}
+
+	for _, tt := range hotelIDTests {
+		t.Run("hotel ids: "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, index.hotelIDs(tt.ids))
+		})
+	}
+
+	destinationIDTests := []struct {
+		name     string
+		ids      []string
+		expected []int
+	}{
+		{name: "single id", ids: []string{"5432"}, expected: []int{0, 1}},
+		{name: "mixed ids", ids: []string{"1122", "5432"}, expected: []int{2, 3, 0, 1}},
+		{name: "unknown ids are skipped", ids: []string{"9999", "1122"}, expected: []int{2, 3}},
+		{name: "non numeric ids are skipped", ids: []string{"iJhz", "1122"}, expected: []int{2, 3}},
+		{name: "only unknown ids", ids: []string{"9999"}, expected: []int{}},
+		{name: "repeated ids are returned once", ids: []string{"1122", " 1122"}, expected: []int{2, 3}},
+	}
+
+	for _, tt := range destinationIDTests {
+		t.Run("destination ids: "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, index.destinationIDs(tt.ids))
+		})
+	}
+
+	t.Run("secondary indexes", func(t *testing.T) {
+		assert.Equal(t, []int{0, 1}, index.countries([]string{"singapore"}))
+		assert.Equal(t, []int{0, 1, 2}, index.countries([]string{"SG", "japan"}))
+		assert.Equal(t, []int{2}, index.cities([]string{" TOKYO"}))
+		assert.Equal(t, []int{0, 2}, index.amenities([]string{"WiFi"}, false))
+		assert.Equal(t, []int{}, index.amenities([]string{"wifi", "tv"}, false))
+		assert.Equal(t, []int{0, 1, 2}, index.amenities([]string{"wifi", "tv"}, true))
+		assert.Equal(t, []int{}, index.countries([]string{"malaysia"}))
+	})
+}
+
+func TestSnapshotFilterHotels(t *testing.T) {
+	hotels := []dto.Hotel{
+		{HotelID: "iJhz", DestinationID: 5432},
+		{HotelID: "SjyX", DestinationID: 5432},
+		{HotelID: "f8c9", DestinationID: 1122},
+	}
+	snapshot := &HotelSnapshot{hotels: hotels, index: newHotelIndex(hotels)}
+
+	tests := []struct {
+		name     string
+		req      *dto.ListHotelsRequest
+		expected []string
+	}{
+		{name: "no filter", req: &dto.ListHotelsRequest{}, expected: []string{"iJhz", "SjyX", "f8c9"}},
+		{name: "hotel ids", req: &dto.ListHotelsRequest{HotelIDs: []string{"f8c9", "unknown"}}, expected: []string{"f8c9"}},
+		{name: "destination ids", req: &dto.ListHotelsRequest{DestinationIDs: []string{"5432"}}, expected: []string{"iJhz", "SjyX"}},
+		{name: "hotel ids and destination ids", req: &dto.ListHotelsRequest{HotelIDs: []string{"f8c9", "iJhz"}, DestinationIDs: []string{"5432"}}, expected: []string{"iJhz"}},
+		{name: "hotel ids and destination ids without a common hotel", req: &dto.ListHotelsRequest{HotelIDs: []string{"f8c9"}, DestinationIDs: []string{"5432"}}, expected: []string{}},
+		{name: "match all", req: &dto.ListHotelsRequest{HotelIDs: []string{"SjyX"}, DestinationIDs: []string{"5432", "1122"}, Match: dto.MatchAll}, expected: []string{"SjyX"}},
+		{name: "match any", req: &dto.ListHotelsRequest{HotelIDs: []string{"f8c9"}, DestinationIDs: []string{"5432"}, Match: dto.MatchAny}, expected: []string{"iJhz", "SjyX", "f8c9"}},
+		{name: "match any with an unknown filter", req: &dto.ListHotelsRequest{HotelIDs: []string{"unknown"}, DestinationIDs: []string{"1122"}, Match: dto.MatchAny}, expected: []string{"f8c9"}},
+		{name: "unknown ids", req: &dto.ListHotelsRequest{DestinationIDs: []string{"9999"}}, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hotels, _ := snapshot.filterHotels(tt.req)
+			assert.Equal(t, tt.expected, hotelIDs(hotels))
+		})
+	}
+}
+
+func TestListHotelsGeo(t *testing.T) {
+	ctx := context.Background()
+	float32Pointer := func(val float32) *float32 { return &val }
+
+	geoHotels := []Hotel{
+		{HotelID: "bkk", DestinationID: 3, Location: &HotelLocation{Latitude: float32Pointer(13.7563), Longitude: float32Pointer(100.5018)}},
+		{HotelID: "mbs", DestinationID: 2, Location: &HotelLocation{Latitude: float32Pointer(1.2840), Longitude: float32Pointer(103.8607)}},
+		{HotelID: "nyc", DestinationID: 1, Location: &HotelLocation{Latitude: float32Pointer(40.7128)}},
+		{HotelID: "sin", DestinationID: 2, Location: &HotelLocation{Latitude: float32Pointer(1.3521), Longitude: float32Pointer(103.8198)}},
+		{HotelID: "zzz", DestinationID: 2},
+	}
+	singapore := &geo.Point{Lat: 1.3521, Lng: 103.8198}
+
+	usecase := newSnapshotUsecase([]SupplierResult{{Name: Acme, Hotels: geoHotels}})
+
+	tests := []struct {
+		name     string
+		req      *dto.ListHotelsRequest
+		expected []string
+	}{
+		{name: "near", req: &dto.ListHotelsRequest{Near: singapore, RadiusKm: 5}, expected: []string{"sin"}},
+		{name: "near with a larger radius", req: &dto.ListHotelsRequest{Near: singapore, RadiusKm: 10}, expected: []string{"mbs", "sin"}},
+		{name: "near sorted by distance", req: &dto.ListHotelsRequest{Near: singapore, RadiusKm: 10, Sort: dto.SortDistance, Order: dto.OrderDesc}, expected: []string{"mbs", "sin"}},
+		{name: "bounding box", req: &dto.ListHotelsRequest{BoundingBox: &geo.BoundingBox{MinLat: 0, MinLng: 100, MaxLat: 20, MaxLng: 110}}, expected: []string{"bkk", "mbs", "sin"}},
+		{name: "bounding box and destination ids", req: &dto.ListHotelsRequest{BoundingBox: &geo.BoundingBox{MinLat: 0, MinLng: 100, MaxLat: 20, MaxLng: 110}, DestinationIDs: []string{"2"}}, expected: []string{"mbs", "sin"}},
+		{name: "bounding box without hotels", req: &dto.ListHotelsRequest{BoundingBox: &geo.BoundingBox{MinLat: 50, MinLng: 0, MaxLat: 60, MaxLng: 10}}, expected: []string{}},
+		{name: "hotels without coordinates are excluded", req: &dto.ListHotelsRequest{BoundingBox: &geo.BoundingBox{MinLat: -90, MinLng: -180, MaxLat: 90, MaxLng: 180}}, expected: []string{"bkk", "mbs", "sin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := usecase.ListHotels(ctx, tt.req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, hotelIDs(resp.Data))
+		})
+	}
+
+	t.Run("should annotate hotels with their distance", func(t *testing.T) {
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Near: singapore, RadiusKm: 10})
+		assert.NoError(t, err)
+		assert.InDelta(t, 8.9, *resp.Data[0].DistanceKm, 0.1)
+		assert.InDelta(t, 0, *resp.Data[1].DistanceKm, 0.01)
+
+		resp, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{HotelIDs: []string{"sin", "zzz"}})
+		assert.NoError(t, err)
+		assert.Nil(t, resp.Data[0].DistanceKm)
+		assert.Nil(t, resp.Data[1].DistanceKm)
+
+		resp, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{HotelIDs: []string{"sin", "zzz"}, Origin: singapore})
+		assert.NoError(t, err)
+		assert.NotNil(t, resp.Data[0].DistanceKm)
+		assert.Nil(t, resp.Data[1].DistanceKm)
+	})
+
+	t.Run("should fail with an invalid geo query", func(t *testing.T) {
+		invalidRequests := []*dto.ListHotelsRequest{
+			{Near: singapore},
+			{RadiusKm: 5},
+			{Near: singapore, RadiusKm: -5},
+			{Near: &geo.Point{Lat: 91}, RadiusKm: 5},
+			{BoundingBox: &geo.BoundingBox{MinLat: 20, MaxLat: 10}},
+		}
+
+		for _, req := range invalidRequests {
+			_, err := usecase.ListHotels(ctx, req)
+			assert.ErrorIs(t, err, ErrInvalidRequest)
+		}
+	})
+}
+
+func TestListHotelsSearch(t *testing.T) {
+	ctx := context.Background()
+	address := "10 Bayfront Avenue"
+
+	searchHotels := []Hotel{
+		{HotelID: "bay", DestinationID: 1, Name: "Bay Hotel", Description: "A small hotel near Marina Bay", Amenities: []string{"pool"}, Location: &HotelLocation{}},
+		{HotelID: "cafe", DestinationID: 2, Name: "Café Hôtel", Description: "Rooms above a café", Location: &HotelLocation{}},
+		{HotelID: "mbs", DestinationID: 1, Name: "Marina Bay Sands", Description: "Infinity pool overlooking the bay", Amenities: []string{"pool", "wifi"}, Location: &HotelLocation{Address: &address}},
+		{HotelID: "villa", DestinationID: 2, Name: "Beach Villas", Description: "Villas on the beach", Amenities: []string{"pool"}, Location: &HotelLocation{}},
+	}
+
+	usecase := newSnapshotUsecase([]SupplierResult{{Name: Acme, Hotels: searchHotels}})
+
+	tests := []struct {
+		name     string
+		req      *dto.ListHotelsRequest
+		expected []string
+	}{
+		{name: "every word of the query ordered by relevance", req: &dto.ListHotelsRequest{Query: "marina bay pool"}, expected: []string{"mbs", "bay"}},
+		{name: "address", req: &dto.ListHotelsRequest{Query: "bayfront"}, expected: []string{"mbs"}},
+		{name: "amenities", req: &dto.ListHotelsRequest{Query: "outdoor pool"}, expected: []string{"villa", "mbs", "bay"}},
+		{name: "diacritics and case", req: &dto.ListHotelsRequest{Query: "CAFE HOTEL"}, expected: []string{"cafe"}},
+		{name: "no match", req: &dto.ListHotelsRequest{Query: "marina unknown"}, expected: []string{}},
+		{name: "combined with ids", req: &dto.ListHotelsRequest{Query: "pool", DestinationIDs: []string{"1"}}, expected: []string{"mbs", "bay"}},
+		{name: "combined with ids by match any", req: &dto.ListHotelsRequest{Query: "beach", HotelIDs: []string{"cafe"}, Match: dto.MatchAny}, expected: []string{"villa", "cafe"}},
+		{name: "with another sort", req: &dto.ListHotelsRequest{Query: "pool", Sort: dto.SortName}, expected: []string{"bay", "villa", "mbs"}},
+		{name: "relevance ascending", req: &dto.ListHotelsRequest{Query: "marina bay pool", Order: dto.OrderAsc}, expected: []string{"bay", "mbs"}},
+		{name: "punctuation only is no query", req: &dto.ListHotelsRequest{Query: ",."}, expected: []string{"bay", "cafe", "mbs", "villa"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := usecase.ListHotels(ctx, tt.req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, hotelIDs(resp.Data))
+		})
+	}
+
+	t.Run("should page through results in order of relevance", func(t *testing.T) {
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Query: "marina bay pool", Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"mbs"}, hotelIDs(resp.Data))
+
+		resp, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{Query: " Marina  bay POOL", Limit: 1, Cursor: resp.NextCursor})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bay"}, hotelIDs(resp.Data))
+		assert.Empty(t, resp.NextCursor)
+	})
+
+	t.Run("should fail to sort by relevance without a query", func(t *testing.T) {
+		_, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Sort: dto.SortRelevance})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+
+		_, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{Query: ",.", Sort: dto.SortRelevance})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+
+	t.Run("should search the refreshed hotels", func(t *testing.T) {
+		usecase := newSnapshotUsecase([]SupplierResult{{Name: Acme, Hotels: searchHotels}})
+		usecase.setHotelSnapshot(usecase.snapshot.withSource(SupplierResult{Name: Acme, Hotels: searchHotels[:1]}))
+
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Query: "pool"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bay"}, hotelIDs(resp.Data))
+	})
+}
+
+func TestListHotelsAttributes(t *testing.T) {
+	ctx := context.Background()
+	sg := "SG"
+	singapore := " singapore"
+	japan := "Japan"
+	tokyo := "Tokyo"
+	sentosa := "Sentosa"
+
+	attributeHotels := []SupplierResult{
+		{Name: Patagonia, Hotels: []Hotel{
+			{HotelID: "mbs", Amenities: []string{"Pool ", "wifi"}, Location: &HotelLocation{}},
+			{HotelID: "tyo", Amenities: []string{"aircon"}, Location: &HotelLocation{}},
+		}},
+		{Name: Paperflies, Hotels: []Hotel{
+			{HotelID: "mbs", Amenities: []string{"outdoor pool", "tv"}, Location: &HotelLocation{Country: &sg}},
+			{HotelID: "sen", Amenities: []string{"pool", "drycleaning"}, Location: &HotelLocation{Country: &singapore, City: &sentosa}},
+		}},
+		{Name: Acme, Hotels: []Hotel{
+			{HotelID: "tyo", Amenities: []string{"WiFi"}, Location: &HotelLocation{Country: &japan, City: &tokyo}},
+		}},
+	}
+
+	usecase := newSnapshotUsecase(attributeHotels)
+
+	tests := []struct {
+		name     string
+		req      *dto.ListHotelsRequest
+		expected []string
+	}{
+		{name: "supplier amenity name", req: &dto.ListHotelsRequest{Amenities: []string{"pool"}}, expected: []string{"mbs", "sen"}},
+		{name: "canonical amenity name", req: &dto.ListHotelsRequest{Amenities: []string{" Outdoor Pool"}}, expected: []string{"mbs", "sen"}},
+		{name: "all amenities", req: &dto.ListHotelsRequest{Amenities: []string{"pool", "wifi"}}, expected: []string{"mbs"}},
+		{name: "any amenity", req: &dto.ListHotelsRequest{Amenities: []string{"dry cleaning", "aircon"}, AmenityMatch: dto.MatchAny}, expected: []string{"sen", "tyo"}},
+		{name: "unknown amenity", req: &dto.ListHotelsRequest{Amenities: []string{"spa"}}, expected: []string{}},
+		{name: "country name", req: &dto.ListHotelsRequest{Countries: []string{"Singapore"}}, expected: []string{"mbs", "sen"}},
+		{name: "country code", req: &dto.ListHotelsRequest{Countries: []string{"jp"}}, expected: []string{"tyo"}},
+		{name: "countries", req: &dto.ListHotelsRequest{Countries: []string{"sg", "JAPAN"}}, expected: []string{"mbs", "sen", "tyo"}},
+		{name: "city", req: &dto.ListHotelsRequest{Cities: []string{"tokyo"}}, expected: []string{"tyo"}},
+		{name: "country and amenities", req: &dto.ListHotelsRequest{Countries: []string{"singapore"}, Amenities: []string{"tv"}}, expected: []string{"mbs"}},
+		{name: "any amenity and country", req: &dto.ListHotelsRequest{Countries: []string{"singapore"}, Amenities: []string{"tv", "wifi"}, AmenityMatch: dto.MatchAny}, expected: []string{"mbs"}},
+		{name: "city or amenities by match any", req: &dto.ListHotelsRequest{Cities: []string{"sentosa"}, Amenities: []string{"air conditioning", "wifi"}, Match: dto.MatchAny}, expected: []string{"sen", "tyo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := usecase.ListHotels(ctx, tt.req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, hotelIDs(resp.Data))
+		})
+	}
+
+	t.Run("should fail with an invalid amenity match", func(t *testing.T) {
+		_, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Amenities: []string{"pool"}, AmenityMatch: "some"})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"hotel-data-merge/dto"
 	"strings"
+	"time"
 )
 
 const (
@@ -22,6 +23,29 @@ type Hotel struct {
 	BookingConditions []string
 }
 
+// SupplierResult is the outcome of fetching the hotels of a single supplier
+type SupplierResult struct {
+	Name        string
+	Priority    int // lower value first, the order of the suppliers when merging
+	Hotels      []Hotel
+	Err         error
+	Latency     time.Duration
+	StatusCode  int // 0 if the supplier could not be reached
+	Attempts    int
+	NotModified bool // true if the supplier data did not change since the previous fetch
+	RecordCount int
+	FetchedAt   time.Time // when Hotels were fetched, which is the previous fetch if NotModified
+	Stale       bool      // true if the fetch failed and Hotels are from the last successful fetch at FetchedAt
+}
+
+// SupplierHealth is the circuit breaker state of a supplier
+type SupplierHealth struct {
+	Name                string
+	CircuitState        string
+	ConsecutiveFailures int
+	OpenedAt            time.Time
+}
+
 type HotelImages struct {
 	RoomImages     []HotelImage
 	SiteImages     []HotelImage
@@ -52,14 +76,24 @@ func (h *HotelLocation) toDto() *dto.HotelLocation {
 	}
 
 	return &dto.HotelLocation{
-		Latitude:  h.Latitude,
-		Longitude: h.Longitude,
+		Latitude:  copyPointer(h.Latitude),
+		Longitude: copyPointer(h.Longitude),
 		Address:   trimspace(h.Address),
 		City:      trimspace(h.City),
 		Country:   cleanCountryName(h.Country),
 	}
 }
 
+// copyPointer returns a pointer to a copy of the value, so the copy does not share the value with the supplier data
+func copyPointer[T any](val *T) *T {
+	if val == nil {
+		return nil
+	}
+
+	v := *val
+	return &v
+}
+
 func trimspace(val *string) *string {
 	if val == nil {
 		return nil
@@ -69,69 +103,54 @@ func trimspace(val *string) *string {
 	return &v
 }
 
-type PaperfliesHotel struct {
-	HotelID           string            `json:"hotel_id"`
-	DestinationID     int32             `json:"destination_id"`
-	HotelName         string            `json:"hotel_name"`
-	Location          *Location         `json:"location,omitempty"`
-	Details           string            `json:"details,omitempty"`
-	Amenities         *Amenities        `json:"amenities,omitempty"`
-	Images            *PaperfliesImages `json:"images,omitempty"`
-	BookingConditions []string          `json:"booking_conditions,omitempty"`
-}
+// toSourceDto copies the hotel as it was normalized from its supplier, so it is not changed when the hotels are merged
+func (h Hotel) toSourceDto() dto.SourceHotel {
+	source := dto.SourceHotel{
+		HotelID:           h.HotelID,
+		DestinationID:     h.DestinationID,
+		Name:              h.Name,
+		Description:       h.Description,
+		Amenities:         append([]string(nil), h.Amenities...),
+		BookingConditions: append([]string(nil), h.BookingConditions...),
+	}
 
-type Location struct {
-	Address *string `json:"address,omitempty"`
-	Country *string `json:"country,omitempty"`
-}
+	if h.Location != nil {
+		source.Location = &dto.HotelLocation{
+			Latitude:  copyPointer(h.Location.Latitude),
+			Longitude: copyPointer(h.Location.Longitude),
+			Address:   copyPointer(h.Location.Address),
+			City:      copyPointer(h.Location.City),
+			Country:   copyPointer(h.Location.Country),
+		}
+	}
 
-type Amenities struct {
-	GeneralAmenity []string `json:"general,omitempty"`
-	RoomAmenity    []string `json:"room,omitempty"`
-}
+	source.Images = h.Images.toSourceDto()
 
-type PaperfliesImages struct {
-	RoomImages []PaperfliesImage `json:"rooms,omitempty"`
-	SiteImages []PaperfliesImage `json:"site,omitempty"`
+	return source
 }
 
-type PaperfliesImage struct {
-	Link    string `json:"link"`
-	Caption string `json:"caption"`
-}
+// toSourceDto copies the images as they were normalized from their supplier
+func (h *HotelImages) toSourceDto() *dto.HotelImages {
+	if h == nil {
+		return nil
+	}
 
-type PatagoniaHotel struct {
-	HotelID       string           `json:"id"`
-	DestinationID int32            `json:"destination"`
-	HotelName     string           `json:"name"`
-	Latitude      *float32         `json:"lat,omitempty"`
-	Longitude     *float32         `json:"lng,omitempty"`
-	Address       *string          `json:"address,omitempty"`
-	Info          string           `json:"info,omitempty"`
-	Amenities     []string         `json:"amenities,omitempty"`
-	Images        *PatagoniaImages `json:"images,omitempty"`
+	return &dto.HotelImages{
+		RoomImages:     imagesToDto(h.RoomImages),
+		SiteImages:     imagesToDto(h.SiteImages),
+		AmmenityImages: imagesToDto(h.AmmenityImages),
+	}
 }
 
-type PatagoniaImages struct {
-	RoomImages    []PatagoniaImage `json:"rooms,omitempty"`
-	AmenityImages []PatagoniaImage `json:"amenities,omitempty"`
-}
+func imagesToDto(images []HotelImage) []dto.HotelImage {
+	if len(images) == 0 {
+		return nil
+	}
 
-type PatagoniaImage struct {
-	Url         string `json:"url"`
-	Description string `json:"description"`
-}
+	converted := make([]dto.HotelImage, 0, len(images))
+	for _, image := range images {
+		converted = append(converted, dto.HotelImage{Link: image.Link, Description: image.Description})
+	}
 
-type AcmeHotel struct {
-	HotelID       string      `json:"Id"`
-	DestinationID int32       `json:"DestinationId"`
-	HotelName     string      `json:"Name"`
-	Latitude      interface{} `json:"Latitude,omitempty"`
-	Longitude     interface{} `json:"Longitude,omitempty"`
-	Address       *string     `json:"Address,omitempty"`
-	City          *string     `json:"City,omitempty"`
-	Country       *string     `json:"Country,omitempty"`
-	Postcode      *string     `json:"PostalCode,omitempty"`
-	Description   string      `json:"Description,omitempty"`
-	Facilities    []string    `json:"Facilities,omitempty"`
+	return converted
 }
@@ -2,20 +2,38 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"hotel-data-merge/dto"
 	"hotel-data-merge/pkg/cache"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
-func setupHotelTest() (*MockHotelRepository, *cache.MockCacheInterface) {
+var mockCacheConfig = CacheConfig{
+	SoftTTL: 60 * time.Minute,
+	HardTTL: 75 * time.Minute,
+}
+
+// setupHotelTest returns a usecase with the default merge rules and its mock repository and cache
+func setupHotelTest() (*HotelUsecase, *MockHotelRepository, *cache.MockCacheInterface) {
 	mockHotelRepo := &MockHotelRepository{}
 	mockCacheInterface := &cache.MockCacheInterface{}
+	usecase := NewHotelUsecase(mockHotelRepo, mockCacheInterface, mockCacheConfig, DefaultMergeRules())
 
-	return mockHotelRepo, mockCacheInterface
+	return usecase, mockHotelRepo, mockCacheInterface
+}
+
+// newSnapshotUsecase returns a usecase serving the snapshot of the supplier results, merged with the default rules
+func newSnapshotUsecase(results []SupplierResult) *HotelUsecase {
+	usecase, _, _ := setupHotelTest()
+	usecase.setHotelSnapshot(buildSnapshot(results, DefaultMergeRules()))
+
+	return usecase
 }
 
 func TestListHotels(t *testing.T) {
@@ -112,6 +130,15 @@ func TestListHotels(t *testing.T) {
 		}
 	}
 
+	supplierResults := func() []SupplierResult {
+		hotels := normalizedHotels()
+		return []SupplierResult{
+			{Name: Patagonia, Hotels: hotels[Patagonia], StatusCode: 200, RecordCount: 1},
+			{Name: Paperflies, Hotels: hotels[Paperflies], StatusCode: 200, RecordCount: 1},
+			{Name: Acme, Hotels: hotels[Acme], StatusCode: 200, RecordCount: 1},
+		}
+	}
+
 	cleanedCountry := "Singapore"
 	mockReturnedHotels := func() []dto.Hotel {
 		return []dto.Hotel{
@@ -158,14 +185,19 @@ func TestListHotels(t *testing.T) {
 	}
 
 	t.Run("should successfully list hotels without filter and cache", func(t *testing.T) {
-		mockHotelRepo, mockCache := setupHotelTest()
-		usecase := NewHotelUsecase(mockHotelRepo, mockCache)
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
 		ctx := context.Background()
 		mockCache.On("Get", CacheKey).Return(nil, false)
-		mockCache.On("Set", CacheKey, normalizedHotels(), 60*time.Minute)
-		mockHotelRepo.On("ListHotels", ctx).Return(normalizedHotels())
+		mockCache.On("Set", CacheKey, mock.MatchedBy(func(cached cachedHotelSnapshot) bool {
+			return assert.ObjectsAreEqual(supplierResults(), cached.Snapshot.sources)
+		}), mockCacheConfig.HardTTL)
+		for _, result := range supplierResults() {
+			mockCache.On("Set", lastKnownGoodCacheKey(result.Name), result, cache.NoExpiration)
+		}
+		mockHotelRepo.On("ListHotels", ctx).Return(supplierResults())
 
-		hotels := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+		hotels, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+		assert.NoError(t, err)
 
 		assert.NotEmpty(t, hotels)
 		assert.ElementsMatch(t, mockReturnedHotels()[0].Amenities.GeneralAmenity, hotels.Data[0].Amenities.GeneralAmenity)
@@ -180,11 +212,11 @@ func TestListHotels(t *testing.T) {
 	})
 
 	t.Run("should successfully list hotels with cache", func(t *testing.T) {
-		mockHotelRepo, mockCache := setupHotelTest()
-		usecase := NewHotelUsecase(mockHotelRepo, mockCache)
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
 
-		mockCache.On("Get", CacheKey).Return(normalizedHotels(), true)
-		hotels := usecase.ListHotels(context.Background(), &dto.ListHotelsRequest{})
+		mockCache.On("Get", CacheKey).Return(cachedHotelSnapshot{Snapshot: buildSnapshot(supplierResults(), DefaultMergeRules()), FetchedAt: time.Now()}, true)
+		hotels, err := usecase.ListHotels(context.Background(), &dto.ListHotelsRequest{})
+		assert.NoError(t, err)
 
 		assert.NotEmpty(t, hotels)
 		assert.ElementsMatch(t, mockReturnedHotels()[0].Amenities.GeneralAmenity, hotels.Data[0].Amenities.GeneralAmenity)
@@ -198,15 +230,76 @@ func TestListHotels(t *testing.T) {
 		mockHotelRepo.AssertExpectations(t)
 	})
 
+	t.Run("should return the same hotels on every request without modifying the supplier data", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+		ctx := context.Background()
+
+		// every request misses the cache and merges the same supplier results again
+		results := supplierResults()
+		mockCache.On("Get", CacheKey).Return(nil, false)
+		mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything)
+		mockHotelRepo.On("ListHotels", ctx).Return(results)
+
+		first, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, mockReturnedHotels()[0].Images, first.Data[0].Images)
+		assert.Equal(t, mockReturnedHotels()[0].BookingConditions, first.Data[0].BookingConditions)
+
+		for i := 0; i < 5; i++ {
+			hotels, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+			assert.NoError(t, err)
+			assert.Equal(t, first.Data, hotels.Data)
+		}
+		assert.Equal(t, supplierResults(), results)
+	})
+
+	t.Run("should serve concurrent requests while the suppliers are refreshed", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+		ctx := context.Background()
+
+		results := supplierResults()
+		mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything)
+		mockHotelRepo.On("ListHotels", ctx).Return(results)
+		for _, result := range results {
+			mockHotelRepo.On("FetchSupplier", ctx, result.Name).Return(result)
+		}
+		usecase.Refresh(ctx)
+
+		// run with -race to detect the requests and refreshes sharing supplier data
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					if i%4 == 0 {
+						usecase.RefreshSupplier(ctx, results[j%len(results)].Name)
+						continue
+					}
+
+					_, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Query: mockHotelName})
+					assert.NoError(t, err)
+					_, err = usecase.GetHotel(ctx, &dto.GetHotelRequest{HotelID: mockHotelId, Include: []string{dto.IncludeSources, dto.IncludeProvenance}})
+					assert.NoError(t, err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		hotels, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, mockReturnedHotels()[0].Images, hotels.Data[0].Images)
+		assert.Equal(t, supplierResults(), results)
+	})
+
 	t.Run("should successfully list only hotels filtered by destination id", func(t *testing.T) {
-		mockHotelRepo, mockCache := setupHotelTest()
-		usecase := NewHotelUsecase(mockHotelRepo, mockCache)
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
 
-		normalizedHotels := normalizedHotels()
-		mockCache.On("Get", CacheKey).Return(normalizedHotels, true)
-		hotels := usecase.ListHotels(context.Background(), &dto.ListHotelsRequest{
+		mockCache.On("Get", CacheKey).Return(cachedHotelSnapshot{Snapshot: buildSnapshot(supplierResults(), DefaultMergeRules()), FetchedAt: time.Now()}, true)
+		hotels, err := usecase.ListHotels(context.Background(), &dto.ListHotelsRequest{
 			DestinationIDs: []string{"2"},
 		})
+		assert.NoError(t, err)
 
 		assert.Len(t, hotels.Data, 0)
 		mockCache.AssertExpectations(t)
@@ -214,18 +307,216 @@ func TestListHotels(t *testing.T) {
 	})
 
 	t.Run("should successfully list only hotels filtered by hotel id", func(t *testing.T) {
-		mockHotelRepo, mockCache := setupHotelTest()
-		usecase := NewHotelUsecase(mockHotelRepo, mockCache)
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+
+		mockCache.On("Get", CacheKey).Return(cachedHotelSnapshot{Snapshot: buildSnapshot(supplierResults(), DefaultMergeRules()), FetchedAt: time.Now()}, true)
+		hotels, err := usecase.ListHotels(context.Background(), &dto.ListHotelsRequest{
+			HotelIDs: []string{mockHotelId},
+		})
+		assert.NoError(t, err)
+
+		assert.Len(t, hotels.Data, 1)
+		mockCache.AssertExpectations(t)
+		mockHotelRepo.AssertExpectations(t)
+	})
+
+	t.Run("should only list hotels matching both hotel id and destination id", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+
+		mockCache.On("Get", CacheKey).Return(cachedHotelSnapshot{Snapshot: buildSnapshot(supplierResults(), DefaultMergeRules()), FetchedAt: time.Now()}, true)
+		hotels, err := usecase.ListHotels(context.Background(), &dto.ListHotelsRequest{
+			HotelIDs:       []string{mockHotelId},
+			DestinationIDs: []string{"2"},
+		})
+		assert.NoError(t, err)
 
-		normalizedHotels := normalizedHotels()
-		mockCache.On("Get", CacheKey).Return(normalizedHotels, true)
-		hotels := usecase.ListHotels(context.Background(), &dto.ListHotelsRequest{
+		assert.Len(t, hotels.Data, 0)
+		mockCache.AssertExpectations(t)
+		mockHotelRepo.AssertExpectations(t)
+	})
+
+	t.Run("should list hotels matching either hotel id or destination id with match any", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+
+		mockCache.On("Get", CacheKey).Return(cachedHotelSnapshot{Snapshot: buildSnapshot(supplierResults(), DefaultMergeRules()), FetchedAt: time.Now()}, true)
+		hotels, err := usecase.ListHotels(context.Background(), &dto.ListHotelsRequest{
 			HotelIDs:       []string{mockHotelId},
 			DestinationIDs: []string{"2"},
+			Match:          dto.MatchAny,
 		})
+		assert.NoError(t, err)
 
 		assert.Len(t, hotels.Data, 1)
 		mockCache.AssertExpectations(t)
 		mockHotelRepo.AssertExpectations(t)
 	})
+
+	t.Run("should report failed suppliers in the response meta", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+
+		results := supplierResults()
+		results[2] = SupplierResult{Name: Acme, Err: errors.New("mock-error"), StatusCode: 500}
+		mockCache.On("Get", CacheKey).Return(cachedHotelSnapshot{Snapshot: buildSnapshot(results, DefaultMergeRules()), FetchedAt: time.Now()}, true)
+		hotels, err := usecase.ListHotels(context.Background(), &dto.ListHotelsRequest{
+			HotelIDs: []string{mockHotelId},
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, &dto.ResponseMeta{
+			Partial: true,
+			Sources: []dto.SourceMeta{
+				{Name: Patagonia, Status: dto.SourceStatusOK, HTTPStatus: 200, RecordCount: 1},
+				{Name: Paperflies, Status: dto.SourceStatusOK, HTTPStatus: 200, RecordCount: 1},
+				{Name: Acme, Status: dto.SourceStatusError, Error: "mock-error", HTTPStatus: 500},
+			},
+		}, hotels.Meta)
+		mockCache.AssertExpectations(t)
+		mockHotelRepo.AssertExpectations(t)
+	})
+
+	t.Run("should fall back to the last known good data of a failed supplier and cache the results", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+		ctx := context.Background()
+
+		results := supplierResults()
+		lastKnownGood := results[2]
+		lastKnownGood.FetchedAt = time.Now().Add(-2 * time.Hour)
+		results[2] = SupplierResult{Name: Acme, Err: errors.New("mock-error"), StatusCode: 500}
+
+		mockCache.On("Get", CacheKey).Return(nil, false)
+		mockCache.On("Get", lastKnownGoodCacheKey(Acme)).Return(lastKnownGood, true)
+		mockCache.On("Set", lastKnownGoodCacheKey(Patagonia), results[0], cache.NoExpiration)
+		mockCache.On("Set", lastKnownGoodCacheKey(Paperflies), results[1], cache.NoExpiration)
+		mockCache.On("Set", CacheKey, mock.MatchedBy(func(cached cachedHotelSnapshot) bool {
+			return cached.Snapshot.sources[2].Stale
+		}), mockCacheConfig.HardTTL)
+		mockHotelRepo.On("ListHotels", ctx).Return(results)
+
+		hotels, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+		assert.NoError(t, err)
+
+		assert.False(t, hotels.Meta.Partial)
+		acmeMeta := hotels.Meta.Sources[2]
+		assert.Equal(t, dto.SourceStatusStale, acmeMeta.Status)
+		assert.Equal(t, "mock-error", acmeMeta.Error)
+		assert.Equal(t, 1, acmeMeta.RecordCount)
+		assert.InDelta(t, 2*time.Hour.Seconds(), acmeMeta.StaleAgeSeconds, 1)
+		mockCache.AssertExpectations(t)
+		mockHotelRepo.AssertExpectations(t)
+	})
+
+	t.Run("should mark results partial when a failed supplier has no last known good data", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+		ctx := context.Background()
+
+		results := supplierResults()
+		results[2] = SupplierResult{Name: Acme, Err: errors.New("mock-error")}
+
+		mockCache.On("Get", CacheKey).Return(nil, false)
+		mockCache.On("Get", lastKnownGoodCacheKey(Acme)).Return(nil, false)
+		mockCache.On("Set", lastKnownGoodCacheKey(Patagonia), results[0], cache.NoExpiration)
+		mockCache.On("Set", lastKnownGoodCacheKey(Paperflies), results[1], cache.NoExpiration)
+		mockHotelRepo.On("ListHotels", ctx).Return(results)
+
+		hotels, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+		assert.NoError(t, err)
+
+		assert.True(t, hotels.Meta.Partial)
+		assert.Equal(t, dto.SourceStatusError, hotels.Meta.Sources[2].Status)
+		mockCache.AssertNotCalled(t, "Set", CacheKey, mock.Anything, mock.Anything)
+		mockCache.AssertExpectations(t)
+		mockHotelRepo.AssertExpectations(t)
+	})
+}
+
+func TestHealth(t *testing.T) {
+	t.Run("should successfully return the health of every supplier", func(t *testing.T) {
+		usecase, mockHotelRepo, _ := setupHotelTest()
+		openedAt := time.Now()
+
+		mockHotelRepo.On("SupplierHealth").Return([]SupplierHealth{
+			{Name: Patagonia, CircuitState: "closed"},
+			{Name: Acme, CircuitState: "open", ConsecutiveFailures: 3, OpenedAt: openedAt},
+		})
+
+		health := usecase.Health(context.Background())
+
+		assert.Equal(t, &dto.HealthResponse{
+			Suppliers: []dto.SupplierHealth{
+				{Name: Patagonia, CircuitState: "closed"},
+				{Name: Acme, CircuitState: "open", ConsecutiveFailures: 3, OpenedAt: &openedAt},
+			},
+		}, health)
+		mockHotelRepo.AssertExpectations(t)
+	})
+}
+
+func TestCachedHotelSnapshot(t *testing.T) {
+	mockResults := func() []SupplierResult {
+		return []SupplierResult{
+			{Name: Acme, Hotels: []Hotel{{HotelID: "mock-hotel-id"}}, StatusCode: 200, RecordCount: 1},
+		}
+	}
+
+	t.Run("should serve a snapshot past the soft ttl while refreshing it in the background", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+		refreshed := make(chan struct{})
+
+		staleSnapshot := buildSnapshot([]SupplierResult{{Name: Acme, RecordCount: 2}}, DefaultMergeRules())
+		mockCache.On("Get", CacheKey).Return(cachedHotelSnapshot{
+			Snapshot:  staleSnapshot,
+			FetchedAt: time.Now().Add(-mockCacheConfig.SoftTTL),
+		}, true)
+		mockCache.On("Set", lastKnownGoodCacheKey(Acme), mockResults()[0], cache.NoExpiration)
+		mockCache.On("Set", CacheKey, mock.Anything, mockCacheConfig.HardTTL).Run(func(args mock.Arguments) {
+			close(refreshed)
+		}).Once()
+		mockHotelRepo.On("ListHotels", mock.Anything).Return(mockResults()).Once()
+
+		snapshot := usecase.cachedHotelSnapshot(context.Background())
+
+		assert.Same(t, staleSnapshot, snapshot)
+		select {
+		case <-refreshed:
+		case <-time.After(time.Second):
+			t.Fatal("cached results were not refreshed in the background")
+		}
+		mockHotelRepo.AssertExpectations(t)
+	})
+
+	t.Run("should fetch once for concurrent requests on a cache miss", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+		release := make(chan struct{})
+
+		mockCache.On("Get", CacheKey).Return(nil, false)
+		mockCache.On("Set", lastKnownGoodCacheKey(Acme), mockResults()[0], cache.NoExpiration)
+		mockCache.On("Set", CacheKey, mock.Anything, mockCacheConfig.HardTTL).Once()
+		mockHotelRepo.On("ListHotels", mock.Anything).Run(func(args mock.Arguments) {
+			<-release
+		}).Return(mockResults()).Once()
+
+		var wg sync.WaitGroup
+		snapshots := make([]*HotelSnapshot, 10)
+		for i := range snapshots {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				snapshots[i] = usecase.cachedHotelSnapshot(context.Background())
+			}(i)
+		}
+
+		// the fetch is only released once every other request waits for it, so none of them fetches again
+		assert.Eventually(t, func() bool {
+			return usecase.refreshGroup.Waiting(CacheKey) == len(snapshots)-1
+		}, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, mockResults(), snapshots[0].sources)
+		for _, snapshot := range snapshots {
+			assert.Same(t, snapshots[0], snapshot)
+		}
+		mockCache.AssertExpectations(t)
+		mockHotelRepo.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"time"
+)
+
+// Refresh fetches every supplier and replaces the snapshot that requests read from. Suppliers refreshed on their own
+// while they were fetched keep their newer result
+func (u *HotelUsecase) Refresh(ctx context.Context) {
+	start := time.Now()
+	results := u.withLastKnownGood(u.hotelRepo.ListHotels(ctx))
+
+	u.refreshMutex.Lock()
+	defer u.refreshMutex.Unlock()
+
+	snapshot, _ := u.hotelSnapshot()
+	applied := make([]SupplierResult, 0, len(results))
+	for _, result := range results {
+		if current, ok := snapshot.source(result.Name); ok && u.fetchStartedAt[result.Name].After(start) {
+			applied = append(applied, current)
+			continue
+		}
+
+		u.fetchStartedAt[result.Name] = start
+		applied = append(applied, result)
+	}
+	u.setHotelSnapshot(buildSnapshot(applied, u.mergeRules))
+}
+
+// RefreshSupplier fetches a single supplier and rebuilds the snapshot with its new result, keeping the other suppliers as they are
+func (u *HotelUsecase) RefreshSupplier(ctx context.Context, name string) {
+	start := time.Now()
+	result := u.withLastKnownGood([]SupplierResult{u.hotelRepo.FetchSupplier(ctx, name)})[0]
+
+	// refreshes are applied one at a time, and a result is only applied if no fetch of the supplier that started later
+	// was applied first, so a slower refresh does not overwrite newer data. Requests keep reading the previous snapshot
+	// until the new one is built
+	u.refreshMutex.Lock()
+	defer u.refreshMutex.Unlock()
+
+	if u.fetchStartedAt[name].After(start) {
+		return
+	}
+	u.fetchStartedAt[name] = start
+
+	snapshot, ok := u.hotelSnapshot()
+	if !ok {
+		u.setHotelSnapshot(buildSnapshot([]SupplierResult{result}, u.mergeRules))
+		return
+	}
+	u.setHotelSnapshot(snapshot.withSource(result))
+}
+
+func (u *HotelUsecase) setHotelSnapshot(snapshot *HotelSnapshot) {
+	u.snapshotMutex.Lock()
+	defer u.snapshotMutex.Unlock()
+
+	u.snapshot = snapshot
+}
+
+func (u *HotelUsecase) hotelSnapshot() (*HotelSnapshot, bool) {
+	u.snapshotMutex.RLock()
+	defer u.snapshotMutex.RUnlock()
+
+	return u.snapshot, u.snapshot != nil
+}
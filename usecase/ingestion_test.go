@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"hotel-data-merge/dto"
+	"hotel-data-merge/pkg/cache"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRefresh(t *testing.T) {
+	mockResults := func() []SupplierResult {
+		return []SupplierResult{
+			{Name: Patagonia, Hotels: []Hotel{{HotelID: "mock-hotel-id", Location: &HotelLocation{}}}, StatusCode: 200, RecordCount: 1},
+			{Name: Acme, Hotels: []Hotel{{HotelID: "mock-hotel-id", Location: &HotelLocation{}}}, StatusCode: 200, RecordCount: 1},
+		}
+	}
+
+	t.Run("should serve requests from the refreshed snapshot without fetching", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+		ctx := context.Background()
+
+		mockHotelRepo.On("ListHotels", ctx).Return(mockResults()).Once()
+		for _, result := range mockResults() {
+			mockCache.On("Set", lastKnownGoodCacheKey(result.Name), result, cache.NoExpiration)
+		}
+
+		usecase.Refresh(ctx)
+		hotels, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []dto.SourceMeta{
+			{Name: Patagonia, Status: dto.SourceStatusOK, HTTPStatus: 200, RecordCount: 1},
+			{Name: Acme, Status: dto.SourceStatusOK, HTTPStatus: 200, RecordCount: 1},
+		}, hotels.Meta.Sources)
+		mockCache.AssertNotCalled(t, "Get", CacheKey)
+		mockCache.AssertExpectations(t)
+		mockHotelRepo.AssertExpectations(t)
+	})
+
+	t.Run("should only replace the refreshed supplier in the snapshot", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+		ctx := context.Background()
+
+		results := mockResults()
+		mockHotelRepo.On("ListHotels", ctx).Return(results).Once()
+		mockHotelRepo.On("FetchSupplier", ctx, Acme).Return(SupplierResult{Name: Acme, Err: errors.New("mock-error")}).Once()
+		for _, result := range results {
+			mockCache.On("Set", lastKnownGoodCacheKey(result.Name), result, cache.NoExpiration)
+		}
+		mockCache.On("Get", lastKnownGoodCacheKey(Acme)).Return(results[1], true)
+
+		usecase.Refresh(ctx)
+		previousSnapshot, _ := usecase.hotelSnapshot()
+		usecase.RefreshSupplier(ctx, Acme)
+		hotels, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, dto.SourceStatusOK, hotels.Meta.Sources[0].Status)
+		assert.Equal(t, dto.SourceStatusStale, hotels.Meta.Sources[1].Status)
+		assert.Equal(t, 1, hotels.Meta.Sources[1].RecordCount)
+		assert.Equal(t, mockResults(), previousSnapshot.sources)
+		mockCache.AssertExpectations(t)
+		mockHotelRepo.AssertExpectations(t)
+	})
+
+	t.Run("should keep a supplier refreshed while every supplier is fetched", func(t *testing.T) {
+		usecase, mockHotelRepo, mockCache := setupHotelTest()
+		ctx := context.Background()
+
+		refreshed := SupplierResult{Name: Acme, Hotels: []Hotel{{HotelID: "new-hotel-id", Location: &HotelLocation{}}}, StatusCode: 200, RecordCount: 1}
+		fetching, release := make(chan struct{}), make(chan struct{})
+		mockHotelRepo.On("ListHotels", ctx).Return(mockResults()).Run(func(mock.Arguments) {
+			close(fetching)
+			<-release
+		}).Once()
+		mockHotelRepo.On("FetchSupplier", ctx, Acme).Return(refreshed).Once()
+		mockCache.On("Set", mock.Anything, mock.Anything, cache.NoExpiration)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			usecase.Refresh(ctx)
+		}()
+
+		// the supplier is refreshed after the full refresh started fetching, and applied before it
+		<-fetching
+		usecase.RefreshSupplier(ctx, Acme)
+		close(release)
+		<-done
+
+		snapshot, _ := usecase.hotelSnapshot()
+		assert.Equal(t, []SupplierResult{mockResults()[0], refreshed}, snapshot.sources)
+		mockHotelRepo.AssertExpectations(t)
+	})
+}
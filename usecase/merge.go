@@ -0,0 +1,449 @@
+package usecase
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// strategies selecting the merged value of a field from the values of the suppliers
+const (
+	// StrategyFirstNonNil keeps the value of the first supplier that has one
+	StrategyFirstNonNil = "first_non_nil"
+	// StrategyPriority keeps the value of the first of the configured suppliers that has one, then of the other suppliers
+	StrategyPriority = "priority"
+	// StrategyLongest keeps the longest value, or the list with the most items
+	StrategyLongest = "longest"
+	// StrategyMajority keeps the value most suppliers agree on, ignoring case and accents
+	StrategyMajority = "majority"
+	// StrategyMostRecent keeps the value of the supplier that was fetched last
+	StrategyMostRecent = "most_recent"
+	// StrategyAppend combines the values of every supplier, only for list fields
+	StrategyAppend = "append"
+)
+
+// FieldValue is the value of a hotel field from a single supplier
+type FieldValue struct {
+	Supplier  string
+	Value     interface{}
+	FetchedAt time.Time
+}
+
+// MergeStrategy selects the merged values of a field from the values of every supplier with the hotel, in supplier order.
+// Suppliers without a value for the field are left out. The first selected value is used for single value fields,
+// and the selected values are combined for list fields
+type MergeStrategy func(values []FieldValue) []FieldValue
+
+// FieldRule is the merge strategy of a field. Suppliers are the preferred suppliers of the priority strategy
+type FieldRule struct {
+	Strategy  string
+	Suppliers []string
+}
+
+// MergeRules holds the merge strategy of every field, and when the suppliers of a hotel are reported as conflicting
+type MergeRules struct {
+	strategies map[string]namedStrategy
+	Conflicts  ConflictConfig
+}
+
+type namedStrategy struct {
+	name  string // recorded as the rule in the provenance of the field
+	merge MergeStrategy
+}
+
+// mergeFields are the fields selected by the merge rules, with their default rule
+var mergeFields = []struct {
+	field string
+	rule  FieldRule
+}{
+	{FieldDestinationID, FieldRule{Strategy: StrategyFirstNonNil}},
+	{FieldName, FieldRule{Strategy: StrategyLongest}},
+	{FieldDescription, FieldRule{Strategy: StrategyLongest}},
+	{FieldAddress, FieldRule{Strategy: StrategyFirstNonNil}},
+	{FieldCity, FieldRule{Strategy: StrategyFirstNonNil}},
+	{FieldCountry, FieldRule{Strategy: StrategyFirstNonNil}},
+	{FieldLatitude, FieldRule{Strategy: StrategyFirstNonNil}},
+	{FieldLongitude, FieldRule{Strategy: StrategyFirstNonNil}},
+	{FieldAmenities, FieldRule{Strategy: StrategyAppend}},
+	{FieldImages, FieldRule{Strategy: StrategyAppend}},
+	{FieldBookingConditions, FieldRule{Strategy: StrategyAppend}},
+}
+
+// listFields are the fields that combine the selected values
+var listFields = map[string]bool{
+	FieldAmenities:         true,
+	FieldImages:            true,
+	FieldBookingConditions: true,
+}
+
+// DefaultMergeRules keeps the first location values, the longest name and description, and combines the lists of every supplier
+func DefaultMergeRules() MergeRules {
+	rules, _ := NewMergeRules(nil, nil)
+	return rules
+}
+
+// NewMergeRules builds the merge rules with the default rule for the fields without a rule, and the default conflict thresholds.
+// A strategy is one of the builtin strategies, or the name of one of the custom strategies
+func NewMergeRules(fields map[string]FieldRule, custom map[string]MergeStrategy) (MergeRules, error) {
+	rules := MergeRules{
+		strategies: map[string]namedStrategy{},
+		Conflicts:  DefaultConflictConfig(),
+	}
+	for _, f := range mergeFields {
+		rule, ok := fields[f.field]
+		if !ok {
+			rule = f.rule
+		}
+
+		strategy, err := mergeStrategy(f.field, rule, custom)
+		if err != nil {
+			return MergeRules{}, fmt.Errorf("merge field %s: %v", f.field, err)
+		}
+		rules.strategies[f.field] = namedStrategy{name: rule.Strategy, merge: strategy}
+	}
+
+	for field := range fields {
+		if _, ok := rules.strategies[field]; !ok {
+			return MergeRules{}, fmt.Errorf("merge field %s: unknown field", field)
+		}
+	}
+
+	return rules, nil
+}
+
+func mergeStrategy(field string, rule FieldRule, custom map[string]MergeStrategy) (MergeStrategy, error) {
+	if rule.Strategy != StrategyPriority && len(rule.Suppliers) > 0 {
+		return nil, fmt.Errorf("suppliers are only used by the %s strategy", StrategyPriority)
+	}
+
+	switch rule.Strategy {
+	case StrategyFirstNonNil:
+		return firstNonNil, nil
+	case StrategyPriority:
+		if len(rule.Suppliers) == 0 {
+			return nil, fmt.Errorf("the %s strategy needs suppliers", StrategyPriority)
+		}
+		return priority(rule.Suppliers), nil
+	case StrategyLongest:
+		return longest, nil
+	case StrategyMajority:
+		return majority, nil
+	case StrategyMostRecent:
+		return mostRecent, nil
+	case StrategyAppend:
+		if !listFields[field] {
+			return nil, fmt.Errorf("the %s strategy is only supported for %s, %s and %s", StrategyAppend, FieldAmenities, FieldImages, FieldBookingConditions)
+		}
+		return appendAll, nil
+	}
+
+	if strategy, ok := custom[rule.Strategy]; ok {
+		return strategy, nil
+	}
+
+	return nil, fmt.Errorf("unknown strategy %q", rule.Strategy)
+}
+
+func firstNonNil(values []FieldValue) []FieldValue {
+	return values[:1]
+}
+
+// priority orders the values by the suppliers, and the values of other suppliers after them
+func priority(suppliers []string) MergeStrategy {
+	ranks := make(map[string]int, len(suppliers))
+	for i, supplier := range suppliers {
+		ranks[supplier] = i
+	}
+
+	rank := func(supplier string) int {
+		if r, ok := ranks[supplier]; ok {
+			return r
+		}
+		return len(suppliers)
+	}
+
+	return func(values []FieldValue) []FieldValue {
+		best := values[0]
+		for _, value := range values[1:] {
+			if rank(value.Supplier) < rank(best.Supplier) {
+				best = value
+			}
+		}
+
+		return []FieldValue{best}
+	}
+}
+
+// longest keeps the first of the longest values
+func longest(values []FieldValue) []FieldValue {
+	best := values[0]
+	for _, value := range values[1:] {
+		if valueLength(value.Value) > valueLength(best.Value) {
+			best = value
+		}
+	}
+
+	return []FieldValue{best}
+}
+
+// majority keeps the most common value, which is the first of them on a tie
+func majority(values []FieldValue) []FieldValue {
+	counts := map[string]int{}
+	first := map[string]FieldValue{}
+	best := ""
+	for _, value := range values {
+		key := valueKey(value.Value)
+		if _, exists := first[key]; !exists {
+			first[key] = value
+		}
+		counts[key]++
+		if counts[key] > counts[best] {
+			best = key
+		}
+	}
+
+	return []FieldValue{first[best]}
+}
+
+// mostRecent keeps the value of the supplier that was fetched last. A tie keeps the value of the first supplier,
+// in order of priority then name. A supplier that was not modified counts as fetched when its hotels were last returned
+func mostRecent(values []FieldValue) []FieldValue {
+	best := values[0]
+	for _, value := range values[1:] {
+		if value.FetchedAt.After(best.FetchedAt) {
+			best = value
+		}
+	}
+
+	return []FieldValue{best}
+}
+
+func appendAll(values []FieldValue) []FieldValue {
+	return values
+}
+
+func valueLength(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return len(v)
+	case []string:
+		return len(v)
+	case *HotelImages:
+		return len(v.RoomImages) + len(v.SiteImages) + len(v.AmmenityImages)
+	}
+
+	return 0
+}
+
+// valueKey is the value compared by the majority strategy, so values only differing in case, accents or order are equal
+func valueKey(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return indexKey(&v)
+	case []string:
+		keys := make([]string, 0, len(v))
+		for _, s := range v {
+			keys = append(keys, indexKey(&s))
+		}
+		sort.Strings(keys)
+		return fmt.Sprint(keys)
+	case *HotelImages:
+		return fmt.Sprint(*v)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// valueOrder orders the values of a supplier that has a hotel more than once, so they do not depend on the order of its hotels
+func valueOrder(value interface{}) string {
+	if images, ok := value.(*HotelImages); ok {
+		value = *images
+	}
+
+	return valueKey(value) + "\x00" + fmt.Sprint(value)
+}
+
+// orderSuppliers returns the results ordered by supplier priority, then by name, so the merge does not depend
+// on the order the suppliers were fetched in
+func orderSuppliers(results []SupplierResult) []SupplierResult {
+	ordered := append([]SupplierResult(nil), results...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority < ordered[j].Priority
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+
+	return ordered
+}
+
+// mergeHotelByID merges the hotels of the suppliers with data by hotel id, selecting every field with the merge rules
+// and recording which suppliers its value came from. The supplier hotels are not changed.
+// Strategies get the values in supplier order, and a supplier with a hotel more than once gives its values ordered by value,
+// so the merged hotels are the same whatever the order of the suppliers and their hotels
+func mergeHotelByID(results []SupplierResult, rules MergeRules) (map[string]Hotel, map[string]hotelProvenance) {
+	values := map[string]map[string][]FieldValue{}
+	located := map[string]bool{}
+	ranks := map[string]int{}
+	for i, result := range orderSuppliers(results) {
+		if result.Err != nil && !result.Stale {
+			continue
+		}
+		ranks[result.Name] = i
+
+		for _, hotel := range result.Hotels {
+			fields, exists := values[hotel.HotelID]
+			if !exists {
+				fields = map[string][]FieldValue{}
+				values[hotel.HotelID] = fields
+			}
+
+			located[hotel.HotelID] = located[hotel.HotelID] || hotel.Location != nil
+			for field, value := range hotelFieldValues(hotel) {
+				fields[field] = append(fields[field], FieldValue{Supplier: result.Name, Value: value, FetchedAt: result.FetchedAt})
+			}
+		}
+	}
+
+	mergedHotels := make(map[string]Hotel, len(values))
+	provenances := make(map[string]hotelProvenance, len(values))
+	for id, fields := range values {
+		hotel := Hotel{HotelID: id}
+		if located[id] {
+			hotel.Location = &HotelLocation{}
+		}
+
+		provenance := hotelProvenance{}
+		for field, fieldValues := range fields {
+			if len(fieldValues) > 1 {
+				sort.SliceStable(fieldValues, func(i, j int) bool {
+					a, b := fieldValues[i], fieldValues[j]
+					if a.Supplier != b.Supplier {
+						return ranks[a.Supplier] < ranks[b.Supplier]
+					}
+					return valueOrder(a.Value) < valueOrder(b.Value)
+				})
+			}
+
+			strategy := rules.strategies[field]
+			selected := strategy.merge(fieldValues)
+			setHotelField(&hotel, field, selected)
+
+			for _, value := range selected {
+				provenance.add(field, value.Supplier, strategy.name, provenanceValue(value.Value))
+			}
+		}
+
+		mergedHotels[id] = hotel
+		provenances[id] = provenance
+	}
+
+	return mergedHotels, provenances
+}
+
+// hotelFieldValues returns the fields of the supplier hotel that have a value
+func hotelFieldValues(hotel Hotel) map[string]interface{} {
+	values := map[string]interface{}{}
+	if hotel.DestinationID != 0 {
+		values[FieldDestinationID] = hotel.DestinationID
+	}
+	if hotel.Name != "" {
+		values[FieldName] = hotel.Name
+	}
+	if hotel.Description != "" {
+		values[FieldDescription] = hotel.Description
+	}
+
+	if hotel.Location != nil {
+		if hotel.Location.Address != nil {
+			values[FieldAddress] = *hotel.Location.Address
+		}
+		if hotel.Location.City != nil {
+			values[FieldCity] = *hotel.Location.City
+		}
+		if hotel.Location.Country != nil {
+			values[FieldCountry] = *hotel.Location.Country
+		}
+		if hotel.Location.Latitude != nil {
+			values[FieldLatitude] = *hotel.Location.Latitude
+		}
+		if hotel.Location.Longitude != nil {
+			values[FieldLongitude] = *hotel.Location.Longitude
+		}
+	}
+
+	if len(hotel.Amenities) > 0 {
+		values[FieldAmenities] = hotel.Amenities
+	}
+	if hotel.Images != nil {
+		values[FieldImages] = hotel.Images
+	}
+	if len(hotel.BookingConditions) > 0 {
+		values[FieldBookingConditions] = hotel.BookingConditions
+	}
+
+	return values
+}
+
+// setHotelField sets the field of the merged hotel to the selected values, copying them so the merged hotel
+// does not share any data with the supplier hotels
+func setHotelField(hotel *Hotel, field string, selected []FieldValue) {
+	if len(selected) == 0 {
+		return
+	}
+
+	switch value := selected[0].Value.(type) {
+	case int32:
+		hotel.DestinationID = value
+	case float32:
+		if field == FieldLatitude {
+			hotel.Location.Latitude = &value
+		} else {
+			hotel.Location.Longitude = &value
+		}
+	case string:
+		switch field {
+		case FieldName:
+			hotel.Name = value
+		case FieldDescription:
+			hotel.Description = value
+		case FieldAddress:
+			hotel.Location.Address = &value
+		case FieldCity:
+			hotel.Location.City = &value
+		case FieldCountry:
+			hotel.Location.Country = &value
+		}
+	case []string:
+		combined := []string{}
+		for _, s := range selected {
+			combined = append(combined, s.Value.([]string)...)
+		}
+		if field == FieldAmenities {
+			hotel.Amenities = combined
+		} else {
+			hotel.BookingConditions = combined
+		}
+	case *HotelImages:
+		images := &HotelImages{}
+		for _, s := range selected {
+			source := s.Value.(*HotelImages)
+			images.RoomImages = append(images.RoomImages, source.RoomImages...)
+			images.SiteImages = append(images.SiteImages, source.SiteImages...)
+			images.AmmenityImages = append(images.AmmenityImages, source.AmmenityImages...)
+		}
+		hotel.Images = images
+	}
+}
+
+// provenanceValue copies the value of a supplier for the provenance of a field
+func provenanceValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []string:
+		return append([]string(nil), v...)
+	case *HotelImages:
+		return v.toSourceDto()
+	}
+
+	return value
+}
@@ -0,0 +1,317 @@
+package usecase
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeStrategies(t *testing.T) {
+	now := time.Now()
+	values := []FieldValue{
+		{Supplier: Patagonia, Value: "Marina Bay", FetchedAt: now.Add(-time.Hour)},
+		{Supplier: Acme, Value: "marina bay", FetchedAt: now},
+		{Supplier: Paperflies, Value: "Marina Bay Sands", FetchedAt: now.Add(-time.Minute)},
+	}
+
+	tests := []struct {
+		name     string
+		rule     FieldRule
+		expected []FieldValue
+	}{
+		{
+			name:     "first_non_nil keeps the first value",
+			rule:     FieldRule{Strategy: StrategyFirstNonNil},
+			expected: values[:1],
+		},
+		{
+			name:     "priority keeps the value of the first configured supplier",
+			rule:     FieldRule{Strategy: StrategyPriority, Suppliers: []string{Paperflies, Acme}},
+			expected: values[2:],
+		},
+		{
+			name:     "priority keeps the first value if no supplier is configured for the values",
+			rule:     FieldRule{Strategy: StrategyPriority, Suppliers: []string{"other"}},
+			expected: values[:1],
+		},
+		{
+			name:     "longest keeps the longest value",
+			rule:     FieldRule{Strategy: StrategyLongest},
+			expected: values[2:],
+		},
+		{
+			name:     "majority keeps the first of the most common values ignoring case",
+			rule:     FieldRule{Strategy: StrategyMajority},
+			expected: values[:1],
+		},
+		{
+			name:     "most_recent keeps the value fetched last",
+			rule:     FieldRule{Strategy: StrategyMostRecent},
+			expected: values[1:2],
+		},
+		{
+			name:     "append keeps every value",
+			rule:     FieldRule{Strategy: StrategyAppend},
+			expected: values,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := NewMergeRules(map[string]FieldRule{FieldAmenities: test.rule}, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, rules.strategies[FieldAmenities].merge(values))
+		})
+	}
+}
+
+func TestNewMergeRules(t *testing.T) {
+	lastValue := func(values []FieldValue) []FieldValue {
+		return values[len(values)-1:]
+	}
+
+	t.Run("should use the default rule of the fields without a rule", func(t *testing.T) {
+		rules, err := NewMergeRules(map[string]FieldRule{FieldName: {Strategy: "last"}}, map[string]MergeStrategy{"last": lastValue})
+		assert.NoError(t, err)
+		assert.Equal(t, "last", rules.strategies[FieldName].name)
+		assert.Equal(t, StrategyLongest, rules.strategies[FieldDescription].name)
+		assert.Equal(t, StrategyFirstNonNil, rules.strategies[FieldCity].name)
+		assert.Equal(t, StrategyAppend, rules.strategies[FieldAmenities].name)
+	})
+
+	tests := []struct {
+		name   string
+		fields map[string]FieldRule
+		err    string
+	}{
+		{
+			name:   "unknown field",
+			fields: map[string]FieldRule{"rating": {Strategy: StrategyLongest}},
+			err:    "merge field rating: unknown field",
+		},
+		{
+			name:   "unknown strategy",
+			fields: map[string]FieldRule{FieldName: {Strategy: "last"}},
+			err:    `merge field name: unknown strategy "last"`,
+		},
+		{
+			name:   "priority without suppliers",
+			fields: map[string]FieldRule{FieldName: {Strategy: StrategyPriority}},
+			err:    "merge field name: the priority strategy needs suppliers",
+		},
+		{
+			name:   "suppliers without priority",
+			fields: map[string]FieldRule{FieldName: {Strategy: StrategyLongest, Suppliers: []string{Acme}}},
+			err:    "merge field name: suppliers are only used by the priority strategy",
+		},
+		{
+			name:   "append of a single value field",
+			fields: map[string]FieldRule{FieldName: {Strategy: StrategyAppend}},
+			err:    "merge field name: the append strategy is only supported for amenities, images and booking_conditions",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("should fail on "+test.name, func(t *testing.T) {
+			_, err := NewMergeRules(test.fields, nil)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestMergeHotelByID(t *testing.T) {
+	singapore := "Singapore"
+	sg := "SG"
+	results := []SupplierResult{
+		{Name: Patagonia, Priority: 1, Hotels: []Hotel{{
+			HotelID:           "mbs",
+			Name:              "Marina Bay Sands",
+			Location:          &HotelLocation{City: &singapore},
+			Amenities:         []string{"Pool"},
+			BookingConditions: []string{"No pets"},
+		}}},
+		{Name: Acme, Priority: 3, Hotels: []Hotel{{
+			HotelID:           "mbs",
+			Location:          &HotelLocation{City: &sg},
+			Amenities:         []string{"WiFi"},
+			BookingConditions: []string{"No smoking"},
+		}}},
+		{Name: Paperflies, Priority: 2, Err: assert.AnError, Hotels: []Hotel{{HotelID: "mbs", Name: "Failed supplier"}}},
+	}
+
+	t.Run("should merge with the default rules", func(t *testing.T) {
+		hotels, provenance := mergeHotelByID(results, DefaultMergeRules())
+		assert.Equal(t, map[string]Hotel{
+			"mbs": {
+				HotelID:           "mbs",
+				Name:              "Marina Bay Sands",
+				Location:          &HotelLocation{City: &singapore},
+				Amenities:         []string{"Pool", "WiFi"},
+				BookingConditions: []string{"No pets", "No smoking"},
+			},
+		}, hotels)
+		assert.Len(t, provenance["mbs"][FieldAmenities], 2)
+		assert.Equal(t, Patagonia, provenance["mbs"][FieldCity][0].Supplier)
+	})
+
+	t.Run("should merge with the configured rules", func(t *testing.T) {
+		rules, err := NewMergeRules(map[string]FieldRule{
+			FieldCity:              {Strategy: StrategyPriority, Suppliers: []string{Acme}},
+			FieldBookingConditions: {Strategy: StrategyPriority, Suppliers: []string{Acme}},
+		}, nil)
+		assert.NoError(t, err)
+
+		hotels, provenance := mergeHotelByID(results, rules)
+		assert.Equal(t, "SG", *hotels["mbs"].Location.City)
+		assert.Equal(t, []string{"No smoking"}, hotels["mbs"].BookingConditions)
+		assert.Equal(t, []string{"Pool", "WiFi"}, hotels["mbs"].Amenities)
+		assert.Equal(t, StrategyPriority, provenance["mbs"][FieldCity][0].Rule)
+		assert.Equal(t, Acme, provenance["mbs"][FieldCity][0].Supplier)
+	})
+
+	t.Run("should not keep the most recent value of a supplier that was not modified", func(t *testing.T) {
+		now := time.Now()
+		results := []SupplierResult{
+			{Name: Patagonia, Priority: 1, FetchedAt: now.Add(-time.Hour), NotModified: true, Hotels: []Hotel{{HotelID: "mbs", Name: "Marina Bay Sands"}}},
+			{Name: Acme, Priority: 2, FetchedAt: now, Hotels: []Hotel{{HotelID: "mbs", Name: "Marina Bay Sands Hotel"}}},
+			{Name: Paperflies, Priority: 3, FetchedAt: now, Hotels: []Hotel{{HotelID: "mbs", Name: "MBS"}}},
+		}
+		rules, err := NewMergeRules(map[string]FieldRule{FieldName: {Strategy: StrategyMostRecent}}, nil)
+		assert.NoError(t, err)
+
+		hotels, provenance := mergeHotelByID(results, rules)
+		// Acme and Paperflies are fetched at the same time, so the tie keeps the supplier with the higher priority
+		assert.Equal(t, "Marina Bay Sands Hotel", hotels["mbs"].Name)
+		assert.Equal(t, Acme, provenance["mbs"][FieldName][0].Supplier)
+	})
+}
+
+// randomSupplierResults generates suppliers with overlapping hotels, where values often tie on length, count or fetch time
+func randomSupplierResults(r *rand.Rand) []SupplierResult {
+	one := func(values ...string) string {
+		return values[r.Intn(len(values))]
+	}
+	pick := func(values ...string) *string {
+		if r.Intn(3) == 0 {
+			return nil
+		}
+		v := one(values...)
+		return &v
+	}
+	some := func(values ...string) []string {
+		picked := []string{}
+		for _, v := range values {
+			if r.Intn(2) == 0 {
+				picked = append(picked, v)
+			}
+		}
+		return picked
+	}
+	images := func() []HotelImage {
+		picked := []HotelImage{}
+		for _, link := range some("a.jpg", "b.jpg", "c.jpg") {
+			picked = append(picked, HotelImage{Link: link, Description: one("Lobby", "Pool", "lobby", "Room")})
+		}
+		return picked
+	}
+	now := time.Now()
+
+	results := []SupplierResult{}
+	for _, name := range []string{Acme, Paperflies, Patagonia, "expedia"} {
+		result := SupplierResult{
+			Name:      name,
+			Priority:  r.Intn(2),
+			FetchedAt: now.Add(time.Duration(r.Intn(2)) * time.Minute),
+		}
+		if r.Intn(5) == 0 {
+			result.Err = assert.AnError
+			result.Stale = r.Intn(2) == 0
+		}
+
+		for _, id := range []string{"a", "b", "c", "a"} {
+			if r.Intn(2) == 0 {
+				continue
+			}
+			latitude, longitude := float32(r.Intn(3)), float32(r.Intn(3))
+			result.Hotels = append(result.Hotels, Hotel{
+				HotelID:       id,
+				DestinationID: int32(r.Intn(3)),
+				Name:          one("", "Hotel A", "Hotel B", "hotel a"),
+				Description:   one("", "Nice", "Good", "Lovely"),
+				Location: &HotelLocation{
+					Latitude:  &latitude,
+					Longitude: &longitude,
+					Address:   pick("1 Main St", "2 Main St"),
+					City:      pick("Singapore", "singapore", "Tokyo"),
+					Country:   pick("SG", "Singapore", "JP"),
+				},
+				Amenities:         some("Pool", "WiFi", "wifi", "Aircon"),
+				Images:            &HotelImages{RoomImages: images(), SiteImages: images(), AmmenityImages: images()},
+				BookingConditions: some("No pets", "No smoking"),
+			})
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func shuffleSupplierResults(r *rand.Rand, results []SupplierResult) []SupplierResult {
+	shuffled := make([]SupplierResult, 0, len(results))
+	for _, result := range results {
+		result.Hotels = append([]Hotel(nil), result.Hotels...)
+		r.Shuffle(len(result.Hotels), func(i, j int) {
+			result.Hotels[i], result.Hotels[j] = result.Hotels[j], result.Hotels[i]
+		})
+		shuffled = append(shuffled, result)
+	}
+	r.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	return shuffled
+}
+
+func TestMergeHotelByIDIsDeterministic(t *testing.T) {
+	rulesByName := map[string]MergeRules{"default": DefaultMergeRules()}
+	for _, strategy := range []string{StrategyLongest, StrategyMajority, StrategyMostRecent} {
+		fields := map[string]FieldRule{}
+		for _, f := range mergeFields {
+			fields[f.field] = FieldRule{Strategy: strategy}
+		}
+		rules, err := NewMergeRules(fields, nil)
+		assert.NoError(t, err)
+		rulesByName[strategy] = rules
+	}
+	priorityRules, err := NewMergeRules(map[string]FieldRule{
+		FieldName:              {Strategy: StrategyPriority, Suppliers: []string{Patagonia, Acme}},
+		FieldBookingConditions: {Strategy: StrategyPriority, Suppliers: []string{Paperflies}},
+	}, nil)
+	assert.NoError(t, err)
+	rulesByName[StrategyPriority] = priorityRules
+
+	for name, rules := range rulesByName {
+		rules := rules
+		t.Run("should merge the same hotels whatever the order of the suppliers with the "+name+" rules", func(t *testing.T) {
+			property := func(seed int64) bool {
+				r := rand.New(rand.NewSource(seed))
+				results := randomSupplierResults(r)
+				merged, provenance := mergeHotelByID(results, rules)
+				expected := cleanMergedData(merged)
+
+				for i := 0; i < 10; i++ {
+					shuffled, shuffledProvenance := mergeHotelByID(shuffleSupplierResults(r, results), rules)
+					if !assert.Equal(t, expected, cleanMergedData(shuffled)) || !assert.Equal(t, provenance, shuffledProvenance) {
+						return false
+					}
+				}
+				return true
+			}
+
+			assert.NoError(t, quick.Check(property, &quick.Config{MaxCount: 50}))
+		})
+	}
+}
@@ -13,20 +13,58 @@ type MockHotelRepository struct {
 	mock.Mock
 }
 
+// FetchSupplier provides a mock function with given fields: ctx, name
+func (_m *MockHotelRepository) FetchSupplier(ctx context.Context, name string) SupplierResult {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FetchSupplier")
+	}
+
+	var r0 SupplierResult
+	if rf, ok := ret.Get(0).(func(context.Context, string) SupplierResult); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(SupplierResult)
+	}
+
+	return r0
+}
+
 // ListHotels provides a mock function with given fields: ctx
-func (_m *MockHotelRepository) ListHotels(ctx context.Context) map[string][]Hotel {
+func (_m *MockHotelRepository) ListHotels(ctx context.Context) []SupplierResult {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListHotels")
 	}
 
-	var r0 map[string][]Hotel
-	if rf, ok := ret.Get(0).(func(context.Context) map[string][]Hotel); ok {
+	var r0 []SupplierResult
+	if rf, ok := ret.Get(0).(func(context.Context) []SupplierResult); ok {
 		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(map[string][]Hotel)
+			r0 = ret.Get(0).([]SupplierResult)
+		}
+	}
+
+	return r0
+}
+
+// SupplierHealth provides a mock function with given fields:
+func (_m *MockHotelRepository) SupplierHealth() []SupplierHealth {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for SupplierHealth")
+	}
+
+	var r0 []SupplierHealth
+	if rf, ok := ret.Get(0).(func() []SupplierHealth); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]SupplierHealth)
 		}
 	}
 
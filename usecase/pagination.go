@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"hotel-data-merge/dto"
+	"hotel-data-merge/pkg/search"
+	"sort"
+	"strings"
+)
+
+// MaxLimit is the most hotels returned in a single page
+const MaxLimit = 1000
+
+const cursorVersion = 3
+
+// cursor points at the last hotel of a page. It holds the hotel id and sort key instead of an offset so that it stays
+// valid when the data is refreshed, and the fingerprint of the query so that it is not used with another query
+type cursor struct {
+	Version     int     `json:"v"`
+	AfterID     string  `json:"after"`
+	AfterKey    sortKey `json:"key"`
+	Fingerprint string  `json:"q"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, fingerprint string) (cursor, error) {
+	c := cursor{}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidCursor)
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil || c.AfterID == "" {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidCursor)
+	}
+
+	if c.Version != cursorVersion {
+		return c, fmt.Errorf("%w: cursor is from an older version, start again without a cursor", ErrInvalidCursor)
+	}
+
+	if c.Fingerprint != fingerprint {
+		return c, fmt.Errorf("%w: cursor belongs to a different query, start again without a cursor", ErrInvalidCursor)
+	}
+
+	return c, nil
+}
+
+// queryFingerprint identifies the filters and sort of a request, regardless of the order of the ids
+func queryFingerprint(req *dto.ListHotelsRequest) string {
+	match, amenityMatch := req.Match, req.AmenityMatch
+	if match == "" {
+		match = dto.MatchAll
+	}
+	if amenityMatch == "" {
+		amenityMatch = dto.MatchAll
+	}
+
+	order := dto.OrderAsc
+	if sortDesc(req) {
+		order = dto.OrderDesc
+	}
+
+	origin, near, boundingBox := "", "", ""
+	if req.Origin != nil {
+		origin = fmt.Sprintf("%v,%v", req.Origin.Lat, req.Origin.Lng)
+	}
+	if req.Near != nil {
+		near = fmt.Sprintf("%v,%v,%v", req.Near.Lat, req.Near.Lng, req.RadiusKm)
+	}
+	if req.BoundingBox != nil {
+		boundingBox = fmt.Sprintf("%v,%v,%v,%v", req.BoundingBox.MinLat, req.BoundingBox.MinLng, req.BoundingBox.MaxLat, req.BoundingBox.MaxLng)
+	}
+
+	parts := []string{
+		strings.Join(search.Tokenize(req.Query), " "),
+		sortedList(req.HotelIDs, nil),
+		sortedList(req.DestinationIDs, nil),
+		sortedList(req.Amenities, canonicalAmenity),
+		amenityMatch,
+		sortedList(req.Countries, canonicalCountry),
+		sortedList(req.Cities, nil),
+		match,
+		sortOf(req),
+		order,
+		origin,
+		near,
+		boundingBox,
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(strings.Join(parts, "|")))
+	return fmt.Sprintf("%x", hash.Sum64())
+}
+
+// sortedList joins the values in sorted order, canonicalized first if canonical is set
+func sortedList(values []string, canonical func(string) string) string {
+	list := make([]string, 0, len(values))
+	for _, value := range values {
+		if canonical != nil {
+			value = canonical(value)
+		}
+		list = append(list, value)
+	}
+	sort.Strings(list)
+
+	return strings.Join(list, ",")
+}
+
+// paginate returns the page of hotels after the cursor and the cursor of the next page, if there is one.
+// The hotels must be sorted by sortHotels. A limit of 0 returns every hotel after the cursor
+func paginate(hotels []sortedHotel, req *dto.ListHotelsRequest) ([]dto.Hotel, string, error) {
+	fingerprint := queryFingerprint(req)
+
+	start := 0
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor, fingerprint)
+		if err != nil {
+			return nil, "", err
+		}
+
+		after := sortedHotel{hotel: dto.Hotel{HotelID: c.AfterID}, key: c.AfterKey}
+		desc := sortDesc(req)
+		start = sort.Search(len(hotels), func(i int) bool {
+			return compareSortedHotels(hotels[i], after, desc) > 0
+		})
+	}
+
+	end := len(hotels)
+	if req.Limit > 0 && start+req.Limit < end {
+		end = start + req.Limit
+	}
+
+	page := make([]dto.Hotel, 0, end-start)
+	for _, sorted := range hotels[start:end] {
+		hotel := sorted.hotel
+		hotel.DistanceKm = hotelDistance(hotel, req)
+		page = append(page, hotel)
+	}
+
+	if end == len(hotels) {
+		return page, "", nil
+	}
+
+	last := hotels[end-1]
+	return page, encodeCursor(cursor{
+		Version:     cursorVersion,
+		AfterID:     last.hotel.HotelID,
+		AfterKey:    last.key,
+		Fingerprint: fingerprint,
+	}), nil
+}
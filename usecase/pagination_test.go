@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"hotel-data-merge/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func paginationSupplierResults(ids ...string) []SupplierResult {
+	hotels := []Hotel{}
+	for i, id := range ids {
+		hotels = append(hotels, Hotel{HotelID: id, DestinationID: int32(i%2 + 1), Location: &HotelLocation{}})
+	}
+
+	return []SupplierResult{{Name: Acme, Hotels: hotels, RecordCount: len(hotels)}}
+}
+
+func hotelIDs(hotels []dto.Hotel) []string {
+	ids := []string{}
+	for _, hotel := range hotels {
+		ids = append(ids, hotel.HotelID)
+	}
+
+	return ids
+}
+
+func TestListHotelsPagination(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(ids ...string) *HotelUsecase {
+		usecase := newSnapshotUsecase(paginationSupplierResults(ids...))
+		return usecase
+	}
+
+	t.Run("should return hotels ordered by hotel id", func(t *testing.T) {
+		usecase := setup("f8c9", "SjyX", "iJhz", "5432")
+
+		for i := 0; i < 5; i++ {
+			resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"5432", "SjyX", "f8c9", "iJhz"}, hotelIDs(resp.Data))
+			assert.Equal(t, 4, resp.Total)
+			assert.Empty(t, resp.NextCursor)
+		}
+	})
+
+	t.Run("should page through all hotels", func(t *testing.T) {
+		usecase := setup("a", "b", "c", "d", "e")
+
+		pages := [][]string{}
+		req := &dto.ListHotelsRequest{Limit: 2}
+		for {
+			resp, err := usecase.ListHotels(ctx, req)
+			assert.NoError(t, err)
+			assert.Equal(t, 5, resp.Total)
+			pages = append(pages, hotelIDs(resp.Data))
+
+			if resp.NextCursor == "" {
+				break
+			}
+			req.Cursor = resp.NextCursor
+		}
+
+		assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, pages)
+	})
+
+	t.Run("should page through filtered hotels", func(t *testing.T) {
+		usecase := setup("a", "b", "c", "d", "e")
+
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{DestinationIDs: []string{"1"}, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "c"}, hotelIDs(resp.Data))
+		assert.Equal(t, 3, resp.Total)
+
+		resp, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{DestinationIDs: []string{"1"}, Limit: 2, Cursor: resp.NextCursor})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"e"}, hotelIDs(resp.Data))
+		assert.Empty(t, resp.NextCursor)
+	})
+
+	t.Run("should continue after the last hotel when the data is refreshed", func(t *testing.T) {
+		usecase := setup("a", "b", "c", "d", "e")
+
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, hotelIDs(resp.Data))
+
+		// the last hotel of the page is removed and a hotel is added before and after it
+		usecase.setHotelSnapshot(buildSnapshot(paginationSupplierResults("a", "ab", "bb", "c", "d", "e"), DefaultMergeRules()))
+
+		resp, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{Limit: 2, Cursor: resp.NextCursor})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bb", "c"}, hotelIDs(resp.Data))
+		assert.Equal(t, 6, resp.Total)
+	})
+
+	t.Run("should fail with an invalid cursor", func(t *testing.T) {
+		usecase := setup("a", "b", "c")
+
+		_, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Limit: 1, Cursor: "not-a-cursor"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{Limit: 1, Cursor: encodeCursor(cursor{Version: 0, AfterID: "a", Fingerprint: queryFingerprint(&dto.ListHotelsRequest{})})})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("should fail with the cursor of a different query", func(t *testing.T) {
+		usecase := setup("a", "b", "c")
+
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{DestinationIDs: []string{"1", "2"}, Limit: 1})
+		assert.NoError(t, err)
+
+		_, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{DestinationIDs: []string{"2", "1"}, Limit: 1, Cursor: resp.NextCursor})
+		assert.NoError(t, err)
+
+		_, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{DestinationIDs: []string{"1"}, Limit: 1, Cursor: resp.NextCursor})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		assert.Contains(t, err.Error(), "different query")
+	})
+
+	t.Run("should fail with an invalid request", func(t *testing.T) {
+		usecase := setup("a")
+
+		_, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Limit: MaxLimit + 1})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+
+		_, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{Match: "none"})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+}
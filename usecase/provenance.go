@@ -0,0 +1,26 @@
+package usecase
+
+import "hotel-data-merge/dto"
+
+// merged fields, named like the mapping targets
+const (
+	FieldDestinationID     = "destination_id"
+	FieldName              = "name"
+	FieldDescription       = "description"
+	FieldAddress           = "location.address"
+	FieldCity              = "location.city"
+	FieldCountry           = "location.country"
+	FieldLatitude          = "location.latitude"
+	FieldLongitude         = "location.longitude"
+	FieldAmenities         = "amenities"
+	FieldImages            = "images"
+	FieldBookingConditions = "booking_conditions"
+)
+
+// hotelProvenance records, by merged field, the suppliers the value came from and the strategy that selected it
+type hotelProvenance dto.Provenance
+
+// add records the supplier as one more source of the field
+func (p hotelProvenance) add(field, supplier, rule string, value interface{}) {
+	p[field] = append(p[field], dto.FieldProvenance{Supplier: supplier, Value: value, Rule: rule})
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"fmt"
+	"hotel-data-merge/dto"
+	"hotel-data-merge/pkg/search"
+	"strings"
+)
+
+// hasQuery returns if the query of the request has any word to search for, so a query of only punctuation is no query
+func hasQuery(req *dto.ListHotelsRequest) bool {
+	return len(search.Tokenize(req.Query)) > 0
+}
+
+func validateListHotelsRequest(req *dto.ListHotelsRequest) error {
+	if req.Limit < 0 || req.Limit > MaxLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidRequest, MaxLimit)
+	}
+
+	switch req.Match {
+	case "", dto.MatchAll, dto.MatchAny:
+	default:
+		return fmt.Errorf("%w: match must be %s or %s", ErrInvalidRequest, dto.MatchAll, dto.MatchAny)
+	}
+
+	switch req.AmenityMatch {
+	case "", dto.MatchAll, dto.MatchAny:
+	default:
+		return fmt.Errorf("%w: amenity_match must be %s or %s", ErrInvalidRequest, dto.MatchAll, dto.MatchAny)
+	}
+
+	switch req.Sort {
+	case "", dto.SortName, dto.SortDestinationID, dto.SortCompleteness:
+	case dto.SortRelevance:
+		if !hasQuery(req) {
+			return fmt.Errorf("%w: sort %s requires a query", ErrInvalidRequest, dto.SortRelevance)
+		}
+	case dto.SortDistance:
+		if distanceOrigin(req) == nil {
+			return fmt.Errorf("%w: sort %s requires an origin or near", ErrInvalidRequest, dto.SortDistance)
+		}
+	default:
+		return fmt.Errorf("%w: sort must be one of %s, %s, %s, %s or %s", ErrInvalidRequest,
+			dto.SortName, dto.SortDestinationID, dto.SortCompleteness, dto.SortDistance, dto.SortRelevance)
+	}
+
+	switch req.Order {
+	case "", dto.OrderAsc, dto.OrderDesc:
+	default:
+		return fmt.Errorf("%w: order must be %s or %s", ErrInvalidRequest, dto.OrderAsc, dto.OrderDesc)
+	}
+
+	if req.Origin != nil {
+		if err := req.Origin.Validate(); err != nil {
+			return fmt.Errorf("%w: origin %v", ErrInvalidRequest, err)
+		}
+	}
+
+	if req.Near != nil {
+		if err := req.Near.Validate(); err != nil {
+			return fmt.Errorf("%w: near %v", ErrInvalidRequest, err)
+		}
+	}
+
+	if (req.Near != nil) != (req.RadiusKm != 0) || req.RadiusKm < 0 {
+		return fmt.Errorf("%w: near and a positive radius_km must be given together", ErrInvalidRequest)
+	}
+
+	if req.BoundingBox != nil {
+		if err := req.BoundingBox.Validate(); err != nil {
+			return fmt.Errorf("%w: bbox %v", ErrInvalidRequest, err)
+		}
+	}
+
+	for _, facet := range req.Facets {
+		switch facet {
+		case dto.FacetAmenities, dto.FacetCountry, dto.FacetCity, dto.FacetDestinationID:
+		default:
+			return fmt.Errorf("%w: facets must be %s, %s, %s or %s", ErrInvalidRequest,
+				dto.FacetAmenities, dto.FacetCountry, dto.FacetCity, dto.FacetDestinationID)
+		}
+	}
+
+	for _, include := range req.Include {
+		if strings.TrimSpace(include) != dto.IncludeProvenance {
+			return fmt.Errorf("%w: include must be %s", ErrInvalidRequest, dto.IncludeProvenance)
+		}
+	}
+
+	return nil
+}
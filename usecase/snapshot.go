@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"hotel-data-merge/dto"
+	"sort"
+	"time"
+)
+
+// HotelSnapshot holds the merged and cleaned hotels of a set of supplier results, so the merge only runs when
+// the supplier data is refreshed instead of on every request. A snapshot is never modified once built
+type HotelSnapshot struct {
+	sources []SupplierResult
+	hotels  []dto.Hotel
+	index   hotelIndex
+	builtAt time.Time
+
+	// supplierHotels holds the hotels of every supplier by hotel id, as they were before the merge
+	supplierHotels map[string][]dto.SupplierHotel
+	// provenance holds where every merged field of a hotel came from by hotel id
+	provenance map[string]hotelProvenance
+	// conflicts holds the fields the suppliers of a hotel disagree on by hotel id
+	conflicts map[string][]dto.Conflict
+	rules     MergeRules
+}
+
+// buildSnapshot merges the hotels of the supplier results with the merge rules, cleans them and indexes them for lookups
+func buildSnapshot(sources []SupplierResult, rules MergeRules) *HotelSnapshot {
+	merged, provenance := mergeHotelByID(sources, rules)
+	hotels := cleanMergedData(merged)
+
+	return &HotelSnapshot{
+		sources:        sources,
+		hotels:         hotels,
+		index:          newHotelIndex(hotels),
+		builtAt:        time.Now(),
+		supplierHotels: supplierHotelsByID(sources),
+		provenance:     provenance,
+		conflicts:      detectConflicts(sources, rules.Conflicts),
+		rules:          rules,
+	}
+}
+
+// supplierHotelsByID groups the hotels of the suppliers that have data by hotel id, in the order they are merged in
+func supplierHotelsByID(sources []SupplierResult) map[string][]dto.SupplierHotel {
+	hotels := map[string][]dto.SupplierHotel{}
+	for _, result := range orderSuppliers(sources) {
+		if result.Err != nil && !result.Stale {
+			continue
+		}
+
+		for _, hotel := range result.Hotels {
+			hotels[hotel.HotelID] = append(hotels[hotel.HotelID], dto.SupplierHotel{
+				Supplier: result.Name,
+				Hotel:    hotel.toSourceDto(),
+			})
+		}
+	}
+
+	return hotels
+}
+
+// hotel returns the merged hotel with the id
+func (s *HotelSnapshot) hotel(id string) (dto.Hotel, bool) {
+	position, exists := s.index.byHotelID[id]
+	if !exists {
+		return dto.Hotel{}, false
+	}
+
+	return s.hotels[position], true
+}
+
+// source returns the result of the supplier in the snapshot, false if the snapshot is nil or has no such supplier
+func (s *HotelSnapshot) source(name string) (SupplierResult, bool) {
+	if s == nil {
+		return SupplierResult{}, false
+	}
+
+	for _, result := range s.sources {
+		if result.Name == name {
+			return result, true
+		}
+	}
+
+	return SupplierResult{}, false
+}
+
+// withSource returns a new snapshot with the result of a single supplier replaced, keeping the other suppliers as they are
+func (s *HotelSnapshot) withSource(result SupplierResult) *HotelSnapshot {
+	sources := make([]SupplierResult, 0, len(s.sources)+1)
+	replaced := false
+	for _, existing := range s.sources {
+		if existing.Name == result.Name {
+			existing = result
+			replaced = true
+		}
+		sources = append(sources, existing)
+	}
+
+	if !replaced {
+		sources = append(sources, result)
+	}
+
+	return buildSnapshot(sources, s.rules)
+}
+
+// filterHotels returns the hotels matching the request in snapshot order, or all hotels if there is no filter.
+// If the request has a query, the relevance score of every hotel is returned by hotel id
+func (s *HotelSnapshot) filterHotels(req *dto.ListHotelsRequest) ([]dto.Hotel, map[string]float64) {
+	var scores map[string]float64
+	criteria := [][]int{}
+	if hasQuery(req) {
+		matches := s.index.search(req.Query)
+		positions := make([]int, 0, len(matches))
+		scores = make(map[string]float64, len(matches))
+		for _, match := range matches {
+			positions = append(positions, match.ID)
+			scores[s.hotels[match.ID].HotelID] = match.Score
+		}
+		criteria = append(criteria, positions)
+	}
+	if len(req.HotelIDs) > 0 {
+		criteria = append(criteria, s.index.hotelIDs(req.HotelIDs))
+	}
+	if len(req.DestinationIDs) > 0 {
+		criteria = append(criteria, s.index.destinationIDs(req.DestinationIDs))
+	}
+	if len(req.Amenities) > 0 {
+		criteria = append(criteria, s.index.amenities(req.Amenities, req.AmenityMatch == dto.MatchAny))
+	}
+	if len(req.Countries) > 0 {
+		criteria = append(criteria, s.index.countries(req.Countries))
+	}
+	if len(req.Cities) > 0 {
+		criteria = append(criteria, s.index.cities(req.Cities))
+	}
+	if req.Near != nil {
+		criteria = append(criteria, s.index.near(*req.Near, req.RadiusKm))
+	}
+	if req.BoundingBox != nil {
+		criteria = append(criteria, s.index.boundingBox(*req.BoundingBox))
+	}
+
+	if len(criteria) == 0 {
+		return s.hotels, scores
+	}
+
+	positions := combinePositions(criteria, req.Match == dto.MatchAny)
+	hotels := make([]dto.Hotel, 0, len(positions))
+	for _, position := range positions {
+		hotels = append(hotels, s.hotels[position])
+	}
+
+	return hotels, scores
+}
+
+// combinePositions returns the sorted positions in every criterion, or in any criterion if any is set.
+// The positions within a criterion must be unique
+func combinePositions(criteria [][]int, any bool) []int {
+	counts := map[int]int{}
+	for _, positions := range criteria {
+		for _, position := range positions {
+			counts[position]++
+		}
+	}
+
+	combined := []int{}
+	for position, count := range counts {
+		if any || count == len(criteria) {
+			combined = append(combined, position)
+		}
+	}
+	sort.Ints(combined)
+
+	return combined
+}
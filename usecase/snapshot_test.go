@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"hotel-data-merge/dto"
+	"testing"
+)
+
+// generateSupplierResults returns the results of all 3 suppliers, each having the same number of hotels
+func generateSupplierResults(numHotels int) []SupplierResult {
+	results := []SupplierResult{}
+	for _, supplier := range []string{Patagonia, Paperflies, Acme} {
+		hotels := make([]Hotel, 0, numHotels)
+		for i := 0; i < numHotels; i++ {
+			address := fmt.Sprintf("%d mock-address", i)
+			country := "SG"
+			latitude := float32(i%90) + 0.5
+			longitude := float32(i%180) + 0.5
+			hotels = append(hotels, Hotel{
+				HotelID:           fmt.Sprintf("hotel-%d", i),
+				DestinationID:     int32(i % 100),
+				Name:              fmt.Sprintf("%s mock-name %d", supplier, i),
+				Description:       fmt.Sprintf("%s mock-desc %d", supplier, i),
+				BookingConditions: []string{"mock-booking-conditions"},
+				Location: &HotelLocation{
+					Address:   &address,
+					Country:   &country,
+					Latitude:  &latitude,
+					Longitude: &longitude,
+				},
+				Amenities: []string{"pool", "wifi", "aircon", "tv"},
+				Images: &HotelImages{
+					RoomImages: []HotelImage{{Link: fmt.Sprintf("mock-link-%d", i), Description: "mock-img-desc"}},
+				},
+			})
+		}
+		results = append(results, SupplierResult{Name: supplier, Hotels: hotels, RecordCount: numHotels})
+	}
+
+	return results
+}
+
+// BenchmarkListHotelsMergePerRequest is the per request cost before the snapshot,
+// when every request merged and cleaned the hotels of all suppliers
+func BenchmarkListHotelsMergePerRequest(b *testing.B) {
+	for _, numHotels := range []int{1000, 5000} {
+		b.Run(fmt.Sprintf("%d hotels", numHotels), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				results := generateSupplierResults(numHotels)
+				b.StartTimer()
+
+				merged, _ := mergeHotelByID(results, DefaultMergeRules())
+				cleanMergedData(merged)
+			}
+		})
+	}
+}
+
+// BenchmarkListHotelsFromSnapshot is the per request cost with the hotels merged and cleaned once per refresh
+func BenchmarkListHotelsFromSnapshot(b *testing.B) {
+	for _, numHotels := range []int{1000, 5000} {
+		b.Run(fmt.Sprintf("%d hotels", numHotels), func(b *testing.B) {
+			usecase := newSnapshotUsecase(generateSupplierResults(numHotels))
+			ctx := context.Background()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				usecase.ListHotels(ctx, &dto.ListHotelsRequest{})
+			}
+		})
+	}
+}
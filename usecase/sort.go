@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"hotel-data-merge/dto"
+	"hotel-data-merge/pkg/geo"
+	"sort"
+	"strings"
+)
+
+// sortKey is the value a hotel is sorted by. It is stored in the cursor to continue after the last hotel of a page
+type sortKey struct {
+	Missing bool    `json:"m,omitempty"` // hotels without a value are always last
+	Str     string  `json:"s,omitempty"`
+	Num     float64 `json:"n,omitempty"`
+}
+
+// sortedHotel is a hotel with its sort key, so the key is only computed once per request
+type sortedHotel struct {
+	hotel dto.Hotel
+	key   sortKey
+}
+
+// sortOf returns the sort of the request, which is by relevance if there is a query and by hotel id if not
+func sortOf(req *dto.ListHotelsRequest) string {
+	if req.Sort == "" && hasQuery(req) {
+		return dto.SortRelevance
+	}
+
+	return req.Sort
+}
+
+// sortDesc returns if the request is sorted in descending order, which is the default for relevance
+func sortDesc(req *dto.ListHotelsRequest) bool {
+	if req.Order == "" {
+		return sortOf(req) == dto.SortRelevance
+	}
+
+	return req.Order == dto.OrderDesc
+}
+
+// sortHotels orders the hotels by the sort of the request, then by hotel id
+func sortHotels(hotels []dto.Hotel, scores map[string]float64, req *dto.ListHotelsRequest) []sortedHotel {
+	sorted := make([]sortedHotel, 0, len(hotels))
+	for _, hotel := range hotels {
+		sorted = append(sorted, sortedHotel{hotel: hotel, key: hotelSortKey(hotel, scores, req)})
+	}
+
+	// the snapshot is already ordered by hotel id
+	desc := sortDesc(req)
+	if sortOf(req) == "" && !desc {
+		return sorted
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return compareSortedHotels(sorted[i], sorted[j], desc) < 0
+	})
+
+	return sorted
+}
+
+func hotelSortKey(hotel dto.Hotel, scores map[string]float64, req *dto.ListHotelsRequest) sortKey {
+	switch sortOf(req) {
+	case dto.SortRelevance:
+		score, exists := scores[hotel.HotelID]
+		if !exists {
+			return sortKey{Missing: true}
+		}
+		return sortKey{Num: score}
+	case dto.SortName:
+		return sortKey{Str: strings.ToLower(hotel.Name)}
+	case dto.SortDestinationID:
+		return sortKey{Num: float64(hotel.DestinationID)}
+	case dto.SortCompleteness:
+		return sortKey{Num: float64(completeness(hotel))}
+	case dto.SortDistance:
+		distance := hotelDistance(hotel, req)
+		if distance == nil {
+			return sortKey{Missing: true}
+		}
+		return sortKey{Num: *distance}
+	default:
+		// hotels are sorted by hotel id, so the order also reverses the ids
+		return sortKey{Str: hotel.HotelID}
+	}
+}
+
+// compareSortedHotels compares the sort keys in the given order, with missing keys last, then the hotel ids ascending
+func compareSortedHotels(a, b sortedHotel, desc bool) int {
+	if a.key.Missing != b.key.Missing {
+		if a.key.Missing {
+			return 1
+		}
+		return -1
+	}
+
+	result := strings.Compare(a.key.Str, b.key.Str)
+	if result == 0 && a.key.Num != b.key.Num {
+		result = 1
+		if a.key.Num < b.key.Num {
+			result = -1
+		}
+	}
+
+	if desc {
+		result = -result
+	}
+
+	if result == 0 {
+		result = strings.Compare(a.hotel.HotelID, b.hotel.HotelID)
+	}
+
+	return result
+}
+
+// distanceOrigin returns the point distances are measured from, nil if the request has none
+func distanceOrigin(req *dto.ListHotelsRequest) *geo.Point {
+	if req.Origin != nil {
+		return req.Origin
+	}
+
+	return req.Near
+}
+
+// hotelDistance returns the distance of the hotel from the origin of the request,
+// nil if either the hotel has no coordinates or the request has no origin
+func hotelDistance(hotel dto.Hotel, req *dto.ListHotelsRequest) *float64 {
+	origin := distanceOrigin(req)
+	point, ok := hotelPoint(hotel)
+	if origin == nil || !ok {
+		return nil
+	}
+
+	distance := geo.DistanceKm(*origin, point)
+	return &distance
+}
+
+// hotelPoint returns the coordinates of the hotel, if it has both
+func hotelPoint(hotel dto.Hotel) (geo.Point, bool) {
+	if hotel.Location == nil || hotel.Location.Latitude == nil || hotel.Location.Longitude == nil {
+		return geo.Point{}, false
+	}
+
+	return geo.Point{Lat: float64(*hotel.Location.Latitude), Lng: float64(*hotel.Location.Longitude)}, true
+}
+
+// completeness counts the fields of the hotel that have data
+func completeness(hotel dto.Hotel) int {
+	count := 0
+	for _, present := range []bool{
+		hotel.Name != "",
+		hotel.Description != "",
+		len(hotel.BookingConditions) > 0,
+		hotel.Amenities != nil && len(hotel.Amenities.GeneralAmenity) > 0,
+		hotel.Amenities != nil && len(hotel.Amenities.RoomAmenity) > 0,
+		hotel.Images != nil && len(hotel.Images.RoomImages) > 0,
+		hotel.Images != nil && len(hotel.Images.SiteImages) > 0,
+		hotel.Images != nil && len(hotel.Images.AmmenityImages) > 0,
+	} {
+		if present {
+			count++
+		}
+	}
+
+	if hotel.Location != nil {
+		for _, value := range []*string{hotel.Location.Address, hotel.Location.City, hotel.Location.Country} {
+			if value != nil && *value != "" {
+				count++
+			}
+		}
+
+		if _, ok := hotelPoint(hotel); ok {
+			count++
+		}
+	}
+
+	return count
+}
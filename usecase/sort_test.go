@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"hotel-data-merge/dto"
+	"hotel-data-merge/pkg/geo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListHotelsSort(t *testing.T) {
+	ctx := context.Background()
+	float32Pointer := func(val float32) *float32 { return &val }
+	stringPointer := func(val string) *string { return &val }
+
+	// singapore is the origin, tokyo is further away than bangkok
+	sortedHotels := []Hotel{
+		{HotelID: "bkk", DestinationID: 3, Name: "Bangkok Hotel", Description: "desc", Location: &HotelLocation{Latitude: float32Pointer(13.7563), Longitude: float32Pointer(100.5018)}},
+		{HotelID: "nyc", DestinationID: 1, Name: "new york hotel", Location: &HotelLocation{Latitude: float32Pointer(40.7128)}},
+		{HotelID: "sin", DestinationID: 2, Name: "Singapore Hotel", Description: "desc", Location: &HotelLocation{Address: stringPointer("address"), Latitude: float32Pointer(1.3521), Longitude: float32Pointer(103.8198)}},
+		{HotelID: "tyo", DestinationID: 2, Name: "Tokyo Hotel", Location: &HotelLocation{Latitude: float32Pointer(35.6762), Longitude: float32Pointer(139.6503)}},
+		{HotelID: "zzz", DestinationID: 1, Name: "Anonymous", Location: &HotelLocation{}},
+	}
+	origin := &geo.Point{Lat: 1.3521, Lng: 103.8198}
+
+	usecase := newSnapshotUsecase([]SupplierResult{{Name: Acme, Hotels: sortedHotels}})
+
+	tests := []struct {
+		name     string
+		req      *dto.ListHotelsRequest
+		expected []string
+	}{
+		{name: "default by hotel id", req: &dto.ListHotelsRequest{}, expected: []string{"bkk", "nyc", "sin", "tyo", "zzz"}},
+		{name: "hotel id desc", req: &dto.ListHotelsRequest{Order: dto.OrderDesc}, expected: []string{"zzz", "tyo", "sin", "nyc", "bkk"}},
+		{name: "name ignoring case", req: &dto.ListHotelsRequest{Sort: dto.SortName}, expected: []string{"zzz", "bkk", "nyc", "sin", "tyo"}},
+		{name: "name desc", req: &dto.ListHotelsRequest{Sort: dto.SortName, Order: dto.OrderDesc}, expected: []string{"tyo", "sin", "nyc", "bkk", "zzz"}},
+		{name: "destination id with ties by hotel id", req: &dto.ListHotelsRequest{Sort: dto.SortDestinationID}, expected: []string{"nyc", "zzz", "sin", "tyo", "bkk"}},
+		{name: "destination id desc with ties by hotel id", req: &dto.ListHotelsRequest{Sort: dto.SortDestinationID, Order: dto.OrderDesc}, expected: []string{"bkk", "sin", "tyo", "nyc", "zzz"}},
+		{name: "completeness desc", req: &dto.ListHotelsRequest{Sort: dto.SortCompleteness, Order: dto.OrderDesc}, expected: []string{"sin", "bkk", "tyo", "nyc", "zzz"}},
+		{name: "distance with missing coordinates last", req: &dto.ListHotelsRequest{Sort: dto.SortDistance, Origin: origin}, expected: []string{"sin", "bkk", "tyo", "nyc", "zzz"}},
+		{name: "distance desc with missing coordinates last", req: &dto.ListHotelsRequest{Sort: dto.SortDistance, Order: dto.OrderDesc, Origin: origin}, expected: []string{"tyo", "bkk", "sin", "nyc", "zzz"}},
+		{name: "after filtering", req: &dto.ListHotelsRequest{DestinationIDs: []string{"1", "2"}, Sort: dto.SortName}, expected: []string{"zzz", "nyc", "sin", "tyo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := usecase.ListHotels(ctx, tt.req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, hotelIDs(resp.Data))
+
+			// paging one hotel at a time returns the same order
+			paged := []string{}
+			req := *tt.req
+			req.Limit = 1
+			for {
+				resp, err := usecase.ListHotels(ctx, &req)
+				assert.NoError(t, err)
+				paged = append(paged, hotelIDs(resp.Data)...)
+
+				if resp.NextCursor == "" {
+					break
+				}
+				req.Cursor = resp.NextCursor
+			}
+			assert.Equal(t, tt.expected, paged)
+		})
+	}
+
+	t.Run("should fail with an invalid sort", func(t *testing.T) {
+		invalidRequests := []*dto.ListHotelsRequest{
+			{Sort: "price"},
+			{Sort: dto.SortName, Order: "up"},
+			{Sort: dto.SortDistance},
+			{Sort: dto.SortDistance, Origin: &geo.Point{Lat: 100}},
+		}
+
+		for _, req := range invalidRequests {
+			_, err := usecase.ListHotels(ctx, req)
+			assert.ErrorIs(t, err, ErrInvalidRequest)
+		}
+	})
+
+	t.Run("should fail with the cursor of a different sort", func(t *testing.T) {
+		resp, err := usecase.ListHotels(ctx, &dto.ListHotelsRequest{Sort: dto.SortName, Limit: 1})
+		assert.NoError(t, err)
+
+		_, err = usecase.ListHotels(ctx, &dto.ListHotelsRequest{Sort: dto.SortName, Order: dto.OrderDesc, Limit: 1, Cursor: resp.NextCursor})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
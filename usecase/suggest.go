@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"hotel-data-merge/dto"
+	"strings"
+)
+
+const (
+	DefaultSuggestLimit = 10
+	MaxSuggestLimit     = 50
+)
+
+// Suggest returns the hotel names and cities starting with the prefix, tolerating a single typo
+func (u *HotelUsecase) Suggest(ctx context.Context, req *dto.SuggestRequest) (*dto.SuggestResponse, error) {
+	if strings.TrimSpace(req.Prefix) == "" {
+		return nil, fmt.Errorf("%w: prefix is required", ErrInvalidRequest)
+	}
+
+	if req.Limit < 0 || req.Limit > MaxSuggestLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidRequest, MaxSuggestLimit)
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultSuggestLimit
+	}
+
+	resp := &dto.SuggestResponse{
+		Data: []dto.Suggestion{},
+	}
+
+	for _, match := range u.currentSnapshot(ctx).index.suggest(req.Prefix, limit) {
+		suggestion := dto.Suggestion{
+			Text: match.Text,
+			Type: match.Kind,
+		}
+
+		if match.Kind == dto.SuggestionTypeHotel {
+			suggestion.HotelID = match.Ref
+		} else {
+			suggestion.Count = match.Weight
+		}
+
+		resp.Data = append(resp.Data, suggestion)
+	}
+
+	return resp, nil
+}
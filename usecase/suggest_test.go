@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"hotel-data-merge/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggest(t *testing.T) {
+	ctx := context.Background()
+	singapore := "Singapore"
+	sintra := "Sintra"
+
+	suggestHotels := []Hotel{
+		{HotelID: "mbs", Name: "Marina Bay Sands", Location: &HotelLocation{City: &singapore}},
+		{HotelID: "ic", Name: "InterContinental Singapore", Location: &HotelLocation{City: &singapore}},
+		{HotelID: "pena", Name: "Pena Palace Hotel", Location: &HotelLocation{City: &sintra}},
+	}
+
+	usecase := newSnapshotUsecase([]SupplierResult{{Name: Acme, Hotels: suggestHotels}})
+
+	t.Run("should suggest hotel names and cities, with typo matches last", func(t *testing.T) {
+		resp, err := usecase.Suggest(ctx, &dto.SuggestRequest{Prefix: "sin"})
+		assert.NoError(t, err)
+		assert.Equal(t, []dto.Suggestion{
+			{Text: "Singapore", Type: dto.SuggestionTypeCity, Count: 2},
+			{Text: "Sintra", Type: dto.SuggestionTypeCity, Count: 1},
+			{Text: "InterContinental Singapore", Type: dto.SuggestionTypeHotel, HotelID: "ic"},
+			{Text: "Marina Bay Sands", Type: dto.SuggestionTypeHotel, HotelID: "mbs"},
+		}, resp.Data)
+	})
+
+	t.Run("should suggest with a typo", func(t *testing.T) {
+		resp, err := usecase.Suggest(ctx, &dto.SuggestRequest{Prefix: "Mraina"})
+		assert.NoError(t, err)
+		assert.Equal(t, []dto.Suggestion{{Text: "Marina Bay Sands", Type: dto.SuggestionTypeHotel, HotelID: "mbs"}}, resp.Data)
+	})
+
+	t.Run("should return at most the limit", func(t *testing.T) {
+		resp, err := usecase.Suggest(ctx, &dto.SuggestRequest{Prefix: "sin", Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Data, 1)
+	})
+
+	t.Run("should return no suggestions", func(t *testing.T) {
+		resp, err := usecase.Suggest(ctx, &dto.SuggestRequest{Prefix: "tokyo"})
+		assert.NoError(t, err)
+		assert.Equal(t, []dto.Suggestion{}, resp.Data)
+	})
+
+	t.Run("should fail with an invalid request", func(t *testing.T) {
+		_, err := usecase.Suggest(ctx, &dto.SuggestRequest{Prefix: " "})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+
+		_, err = usecase.Suggest(ctx, &dto.SuggestRequest{Prefix: "sin", Limit: MaxSuggestLimit + 1})
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+}